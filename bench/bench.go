@@ -12,22 +12,27 @@ import (
 )
 
 const (
-	namePointMultG1   = "point_multiplication_g1"
-	namePointMultG2   = "point_multiplication_g2"
-	namePointMultGT   = "point_multiplication_gt"
-	namePointDivG1    = "point_div_g1"
-	namePointDivG2    = "point_div_g2"
-	namePointDivGT    = "point_div_gt"
-	namePointAddG1    = "point_add_g1"
-	namePointAddG2    = "point_add_g2"
-	namePointAddGT    = "point_add_gt"
-	namePointPair     = "point_pair"
-	nameGenerateKey   = "generate_key"
-	nameGenerateReKey = "generate_re_key"
-	nameReEncrypt     = "re_encrypt"
-	nameEncrypt       = "encrypt"
-	nameDecrypt       = "decrypt"
-	nameShareMessage  = "share_message"
+	namePointMultG1        = "point_multiplication_g1"
+	namePointMultG2        = "point_multiplication_g2"
+	namePointMultGT        = "point_multiplication_gt"
+	namePointDivG1         = "point_div_g1"
+	namePointDivG2         = "point_div_g2"
+	namePointDivGT         = "point_div_gt"
+	namePointAddG1         = "point_add_g1"
+	namePointAddG2         = "point_add_g2"
+	namePointAddGT         = "point_add_gt"
+	namePointPair          = "point_pair"
+	nameGenerateKey        = "generate_key"
+	nameGenerateReKey      = "generate_re_key"
+	nameReEncrypt          = "re_encrypt"
+	nameEncrypt            = "encrypt"
+	nameDecrypt            = "decrypt"
+	nameShareMessage       = "share_message"
+	nameReEncryptBatch     = "re_encrypt_batch"
+	nameCalculateKeyPoints = "calculate_key_points"
+	nameKeyPointsOrdinal   = "calculate_key_points_per_ordinal"
+	nameAdaptiveSetup      = "adaptive_setup"
+	nameAdaptiveAnswer     = "adaptive_answer"
 )
 
 type ExecResult struct {
@@ -208,6 +213,69 @@ func ShareMessageResults(sizeMin, sizeMax, sizeRatio, countMin, countMax, countS
 	return
 }
 
+// ReEncryptBatchResults measures re-encrypting count capsules with each of
+// the given worker counts.
+func ReEncryptBatchResults(count int, workers []int) (results []ExecResult) {
+	for _, w := range workers {
+		w := w
+		results = append(results,
+			ExecResult{
+				name:  nameReEncryptBatch,
+				bs:    testing.Benchmark(func(b *testing.B) { benchReEncryptBatch(b, count, w) }),
+				extra: map[string]interface{}{"capsule_count": count, "workers": w},
+			},
+		)
+	}
+	return
+}
+
+// KeyPointsResults compares calculating count key points one ordinal at a
+// time with calculating them by stepping, on each of the given worker counts.
+func KeyPointsResults(count int64, workers []int) (results []ExecResult) {
+	results = append(results,
+		ExecResult{
+			name:  nameKeyPointsOrdinal,
+			bs:    testing.Benchmark(func(b *testing.B) { benchCalculateKeyPointsPerOrdinal(b, count) }),
+			extra: map[string]interface{}{"msg_count": count, "workers": 1},
+		},
+	)
+	for _, w := range workers {
+		w := w
+		results = append(results,
+			ExecResult{
+				name:  nameCalculateKeyPoints,
+				bs:    testing.Benchmark(func(b *testing.B) { benchCalculateKeyPointsWorkers(b, count, w) }),
+				extra: map[string]interface{}{"msg_count": count, "workers": w},
+			},
+		)
+	}
+	return
+}
+
+// AdaptiveOTResults compares the per-query cost of the sender in the current
+// OT, CalculateKeyPoints over count messages, with the one-time setup and the
+// per-query answer of the adaptive OT.
+func AdaptiveOTResults(count int64) []ExecResult {
+	extra := map[string]interface{}{"msg_count": count}
+	return []ExecResult{
+		{
+			name:  nameCalculateKeyPoints,
+			bs:    testing.Benchmark(func(b *testing.B) { benchCalculateKeyPoints(b, count) }),
+			extra: extra,
+		},
+		{
+			name:  nameAdaptiveSetup,
+			bs:    testing.Benchmark(func(b *testing.B) { benchAdaptiveSetup(b, count) }),
+			extra: extra,
+		},
+		{
+			name:  nameAdaptiveAnswer,
+			bs:    testing.Benchmark(func(b *testing.B) { benchAdaptiveAnswer(b, count) }),
+			extra: extra,
+		},
+	}
+}
+
 func Comparison(sizeMin, sizeMax, sizeRatio, countMin, countMax, countStep int64) (
 	ourWork, otPaper, yaoGang []float64, sizes []int64, counts []int64) {
 	for size := sizeMin; size <= sizeMax; size *= sizeRatio {
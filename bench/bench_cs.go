@@ -15,25 +15,27 @@ import (
 )
 
 // Cloud Storage Figure1 includes:
-// Setup (key generation), Chameleon Hash, Merkle Tree, Update Block
+// Setup (key generation), Chameleon Hash, Merkle Tree, Update Block, Audit
 type CSFigure1Result struct {
 	ExecSetupMsTimes  []float64 `json:"exec_setup_ms_times"`
 	ExecHashMsTimes   []float64 `json:"exec_hash_ms_times"`
 	ExecMerkleMsTimes []float64 `json:"exec_merkle_ms_times"`
 	ExecUpdateMsTimes []float64 `json:"exec_update_ms_times"`
+	ExecAuditMsTimes  []float64 `json:"exec_audit_ms_times"`
 	FileSizes         []int64   `json:"file_sizes"`
 	BlockCounts       []int64   `json:"block_counts"`
 	BlockSizes        []int64   `json:"block_sizes"`
 }
 
 // Cloud Storage Figure1 includes:
-// Setup (key generation), Chameleon Hash, Merkle Tree, Update Block
+// Setup (key generation), Chameleon Hash, Merkle Tree, Update Block, Audit
 func CSFigure1(rounds int, blockCounts, blockSizes []int64) (*CSFigure1Result, error) {
 	result := &CSFigure1Result{
 		ExecSetupMsTimes:  nil,
 		ExecHashMsTimes:   nil,
 		ExecMerkleMsTimes: nil,
 		ExecUpdateMsTimes: nil,
+		ExecAuditMsTimes:  nil,
 		FileSizes:         nil,
 		BlockCounts:       make([]int64, len(blockCounts)),
 		BlockSizes:        make([]int64, len(blockSizes)),
@@ -43,9 +45,9 @@ func CSFigure1(rounds int, blockCounts, blockSizes []int64) (*CSFigure1Result, e
 	for _, bCount := range blockCounts {
 		for _, bSize := range blockSizes {
 			fmt.Println("Running CSFigure1", bCount, bSize)
-			var sumSetup, sumHash, sumMerkle, sumUpdate int64
+			var sumSetup, sumHash, sumMerkle, sumUpdate, sumAudit int64
 			for round := 0; round < rounds; round++ {
-				setupT, hashT, merkleT, updateT, err := runCSFigure1(bCount, bSize)
+				setupT, hashT, merkleT, updateT, auditT, err := runCSFigure1(bCount, bSize)
 				if err != nil {
 					return nil, err
 				}
@@ -53,6 +55,7 @@ func CSFigure1(rounds int, blockCounts, blockSizes []int64) (*CSFigure1Result, e
 				sumHash += hashT.Nanoseconds()
 				sumMerkle += merkleT.Nanoseconds()
 				sumUpdate += updateT.Nanoseconds()
+				sumAudit += auditT.Nanoseconds()
 			}
 			roundsF := float64(rounds)
 			result.FileSizes = append(result.FileSizes, bCount*bSize)
@@ -60,12 +63,13 @@ func CSFigure1(rounds int, blockCounts, blockSizes []int64) (*CSFigure1Result, e
 			result.ExecHashMsTimes = append(result.ExecHashMsTimes, ns2ms(sumHash)/roundsF)
 			result.ExecMerkleMsTimes = append(result.ExecMerkleMsTimes, ns2ms(sumMerkle)/roundsF)
 			result.ExecUpdateMsTimes = append(result.ExecUpdateMsTimes, ns2ms(sumUpdate)/roundsF)
+			result.ExecAuditMsTimes = append(result.ExecAuditMsTimes, ns2ms(sumAudit)/roundsF)
 		}
 	}
 	return result, nil
 }
 
-func runCSFigure1(blockCount, blockSize int64) (setupTime, hashTime, merkleTime, updateTime time.Duration, err error) {
+func runCSFigure1(blockCount, blockSize int64) (setupTime, hashTime, merkleTime, updateTime, auditTime time.Duration, err error) {
 	fileSize := blockCount * blockSize
 	// run prepare
 	var mf *fileobj.MemFileObj
@@ -77,19 +81,18 @@ func runCSFigure1(blockCount, blockSize int64) (setupTime, hashTime, merkleTime,
 		return
 	}
 	targetBlockIdx := rand.Int63n(blockCount)
-	var targetM *big.Int
+	var targetBlock []byte
 	var targetCHash chash.ChameleonHash
 	// run setup
 	setupStart := time.Now()
-	var Y curve.Point
-	var x *big.Int
-	if x, Y, err = curve.NewRandomPoint(curve.TypeG1, crand.Reader); err != nil {
+	var td *chash.Trapdoor
+	if td, err = chash.GenerateTrapdoor(crand.Reader); err != nil {
 		return
 	}
-	rs := make([]*big.Int, blockCount)
-	Rs := make([]curve.Point, blockCount)
+	hasher := chash.NewTrapdoorHasher(td)
+	rds := make([]*chash.Randomness, blockCount)
 	for i := int64(0); i < blockCount; i++ {
-		if rs[i], Rs[i], err = curve.NewRandomPoint(curve.TypeG2, crand.Reader); err != nil {
+		if rds[i], err = chash.NewRandomness(crand.Reader); err != nil {
 			return
 		}
 	}
@@ -97,18 +100,18 @@ func runCSFigure1(blockCount, blockSize int64) (setupTime, hashTime, merkleTime,
 	// run chameleon hash
 	hashStart := time.Now()
 	var cHashes []chash.ChameleonHash
+	var hashPoints []curve.Point // kept by the storage for audits
 	for i := int64(0); i < blockCount; i++ {
 		data, err := mf.GetBlock(i)
 		if err != nil {
-			return 0, 0, 0, 0, err
+			return 0, 0, 0, 0, 0, err
 		}
-		res := merkle.SHA256(data)
-		resBi := new(big.Int).SetBytes(res[:])
-		m := resBi.Mod(resBi, curve.Order)
-		ch := chash.ComputeHash(Y, Rs[i], m)
+		hp := hasher.HashPoint(data, rds[i])
+		ch := chash.NewChameleonHash(hp)
 		cHashes = append(cHashes, ch)
+		hashPoints = append(hashPoints, hp)
 		if i == targetBlockIdx {
-			targetM = m
+			targetBlock = data
 			targetCHash = ch
 		}
 	}
@@ -123,15 +126,35 @@ func runCSFigure1(blockCount, blockSize int64) (setupTime, hashTime, merkleTime,
 	merkleTime = time.Since(merkleStart)
 	// run Update Block
 	updateStart := time.Now()
-	newRes := merkle.SHA256(newBlock)
-	newResBi := new(big.Int).SetBytes(newRes[:])
-	newM := newResBi.Mod(newResBi, curve.Order)
-	newCHash, _, _ := chash.ComputeCollision(Y, x, rs[targetBlockIdx], targetM, newM, curve.Order)
+	newCHash, _, err := hasher.Collide(rds[targetBlockIdx], targetBlock, newBlock)
+	if err != nil {
+		return
+	}
 	if !newCHash.Equals(targetCHash) {
 		err = errors.New("invalid chameleon hash collision")
 		return
 	}
 	updateTime = time.Since(updateStart)
+	// run Audit, of the blocks before the update
+	auditStart := time.Now()
+	Rs := make([]curve.Point, blockCount)
+	ms := make([]*big.Int, blockCount)
+	for i := int64(0); i < blockCount; i++ {
+		data, err := mf.GetBlock(i)
+		if err != nil {
+			return 0, 0, 0, 0, 0, err
+		}
+		Rs[i], ms[i] = rds[i].R, chash.MessageToFieldElement(data)
+	}
+	var failed []int
+	if failed, err = chash.BatchVerifyDigests(cHashes, hashPoints, hasher.PublicKey().Y, Rs, ms); err != nil {
+		return
+	}
+	if len(failed) != 0 {
+		err = fmt.Errorf("audit failed blocks %v", failed)
+		return
+	}
+	auditTime = time.Since(auditStart)
 	return
 }
 
@@ -195,12 +218,12 @@ func CSFigure2(rounds int, updateCount int64, blockSizes []int64) (*CSFigure2Res
 func runCSFigure2(updateCount, blockSize int64) (updateTimes []int64, transCosts []int64, err error) {
 	const metadataSize = 64 + 64 // R_prime and other meta
 	// run prepare
-	var Y curve.Point
-	var x *big.Int
-	if x, Y, err = curve.NewRandomPoint(curve.TypeG1, crand.Reader); err != nil {
+	td, err := chash.GenerateTrapdoor(crand.Reader)
+	if err != nil {
 		return
 	}
-	r, R, err := curve.NewRandomPoint(curve.TypeG2, crand.Reader)
+	hasher := chash.NewTrapdoorHasher(td)
+	rd, err := chash.NewRandomness(crand.Reader)
 	if err != nil {
 		return
 	}
@@ -208,8 +231,7 @@ func runCSFigure2(updateCount, blockSize int64) (updateTimes []int64, transCosts
 	if _, err = rand.Read(data); err != nil {
 		return
 	}
-	targetM := new(big.Int).Mod(new(big.Int).SetBytes(merkle.SHA256(data).Ptr().Bytes()), curve.Order)
-	targetCHash := chash.ComputeHash(Y, R, targetM)
+	targetCHash := hasher.Hash(data, rd)
 	var newDataBlocks [][]byte
 	for i := int64(0); i < updateCount; i++ {
 		newData := make([]byte, blockSize)
@@ -223,8 +245,10 @@ func runCSFigure2(updateCount, blockSize int64) (updateTimes []int64, transCosts
 	var sumCost int64
 	for i := range newDataBlocks {
 		updateStart := time.Now()
-		newM := new(big.Int).Mod(new(big.Int).SetBytes(merkle.SHA256(newDataBlocks[i]).Ptr().Bytes()), curve.Order)
-		newCHash, _, _ := chash.ComputeCollision(Y, x, r, targetM, newM, curve.Order)
+		var newCHash chash.ChameleonHash
+		if newCHash, _, err = hasher.Collide(rd, data, newDataBlocks[i]); err != nil {
+			return
+		}
 		if !newCHash.Equals(targetCHash) {
 			err = errors.New("invalid chameleon hash collision")
 			return
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"runtime"
 	"testing"
 
 	"github.com/clarenous/proxyot/bench"
@@ -130,6 +131,64 @@ func TestAliceCommunicationCostResults(t *testing.T) {
 	}
 }
 
+func TestReEncryptBatchResults(t *testing.T) {
+	var count = 100
+	var workers = []int{1, 2, 4, 8, runtime.NumCPU()}
+
+	results := bench.ReEncryptBatchResults(count, workers)
+	for _, res := range results {
+		fmt.Println(res)
+	}
+}
+
+func TestKeyPointsResults(t *testing.T) {
+	var count int64 = 1000
+	var workers = []int{1, 2, 4, runtime.NumCPU()}
+
+	for _, res := range bench.KeyPointsResults(count, workers) {
+		fmt.Println(res)
+	}
+}
+
+func TestAdaptiveOTResults(t *testing.T) {
+	for _, count := range []int64{10, 100} {
+		for _, res := range bench.AdaptiveOTResults(count) {
+			fmt.Println(res)
+		}
+	}
+}
+
+type KOutOfNCommunicationCostResults struct {
+	Count    int64   `json:"count"`
+	Ks       []int64 `json:"ks"`
+	KOfN     []int64 `json:"k_of_n"`
+	Separate []int64 `json:"separate"`
+}
+
+func TestKOutOfNCommunicationCostResults(t *testing.T) {
+	var count int64 = 100
+	var ks = []int64{1, 2, 5, 10, 20, 50}
+
+	kOfN, separate := bench.KOutOfNCommunicationCostResults(count, ks)
+	// generate json output
+	costResults := &KOutOfNCommunicationCostResults{
+		Count:    count,
+		Ks:       ks,
+		KOfN:     kOfN,
+		Separate: separate,
+	}
+	jsonFile, err := os.Create("k_out_of_n_transmission_costs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jsonFile.Close()
+	jsonEncoder := json.NewEncoder(jsonFile)
+	jsonEncoder.SetIndent("", "  ")
+	if err = jsonEncoder.Encode(costResults); err != nil {
+		t.Fatal(err)
+	}
+}
+
 type ComparisonResults struct {
 	SizeMin   int64     `json:"size_min"`
 	SizeMax   int64     `json:"size_max"`
@@ -6,6 +6,7 @@ import (
 
 	"github.com/clarenous/proxyot/curve"
 	msg "github.com/clarenous/proxyot/node/protocol/pb"
+	"github.com/clarenous/proxyot/ot"
 	"github.com/golang/protobuf/proto"
 	"github.com/ipfs/go-cid"
 	"github.com/multiformats/go-multihash"
@@ -44,6 +45,51 @@ func aliceCommunicationCost(messageNumber int64) (cost int64) {
 	return int64(len(recvBytes)) + int64(len(sendbytes))
 }
 
+// KOutOfNCommunicationCostResults compares the bytes Alice receives and sends
+// to share k of count messages, with one k-out-of-n OT against k separate
+// 1-out-of-n OTs.
+func KOutOfNCommunicationCostResults(count int64, ks []int64) (kOfN, separate []int64) {
+	for _, k := range ks {
+		kOfN = append(kOfN, aliceKOutOfNCommunicationCost(count, k))
+		separate = append(separate, k*aliceCommunicationCost(count))
+		fmt.Println(count, k, kOfN[len(kOfN)-1], separate[len(separate)-1])
+	}
+	return
+}
+
+func aliceKOutOfNCommunicationCost(messageNumber, k int64) (cost int64) {
+	// receive, k sealed choices (Y, L) in one request
+	recv := mockOtChoiceRequest()
+	recvBytes, err := marshalProtoMsg(recv)
+	if err != nil {
+		panic(err)
+	}
+	cost += int64(len(recvBytes)) + (k-1)*int64(len(recv.Yp)+len(recv.Lp))
+	// send, the re-keys and the sealed key table
+	send := mockReEncryptRequest(messageNumber)
+	sendBytes, err := marshalProtoMsg(send)
+	if err != nil {
+		panic(err)
+	}
+	cost += int64(len(sendBytes)) + int64(len(mockSealedKeys(messageNumber, k).Marshal()))
+	return
+}
+
+func mockSealedKeys(number, k int64) *ot.SealedKeys {
+	sealed := &ot.SealedKeys{
+		LPrimes: make([]curve.Point, k),
+		Table:   make([][][]byte, k),
+	}
+	for j := range sealed.Table {
+		sealed.LPrimes[j] = mockedG1Point
+		sealed.Table[j] = make([][]byte, number)
+		for i := range sealed.Table[j] {
+			sealed.Table[j][i] = mockedG1Point.Marshal()
+		}
+	}
+	return sealed
+}
+
 func mockOtChoiceRequest() *msg.OtChoiceRequest {
 	return &msg.OtChoiceRequest{
 		Cid:   mockedCid.String(),
@@ -2,6 +2,7 @@ package bench
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io/ioutil"
@@ -90,6 +91,88 @@ func benchReEncrypt(b *testing.B) {
 	}
 }
 
+func benchReEncryptBatch(b *testing.B, count, workers int) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		capsules, reKeys := make([]curve.Point, count), make([]curve.Point, count)
+		for j := range capsules {
+			capsules[j], reKeys[j] = randPoint(curve.TypeG1), randPoint(curve.TypeG2)
+		}
+		b.StartTimer()
+		if _, _, err := pre.ReEncryptBatch(context.Background(), capsules, reKeys, workers); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchCalculateKeyPoints(b *testing.B, count int64) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		Y, L, pkA := randPoint(curve.TypeG1), randPoint(curve.TypeG1), randPoint(curve.TypeG1)
+		b.StartTimer()
+		if _, _, err := ot.CalculateKeyPoints(Y, L, pkA, count); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchCalculateKeyPointsPerOrdinal measures the key points calculated the way
+// ot.CalculateKeyPoints did before stepping, two scalar multiplications per
+// ordinal.
+func benchCalculateKeyPointsPerOrdinal(b *testing.B, count int64) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		Y, L, pkA, t := randPoint(curve.TypeG1), randPoint(curve.TypeG1), randPoint(curve.TypeG1), randFiledElement()
+		b.StartTimer()
+		kps := make([]curve.Point, count)
+		for j := int64(1); j <= count; j++ {
+			kp := curve.NewPoint(curve.TypeG1).ScalarMult(Y, t)
+			temp := curve.NewPoint(curve.TypeG1).ScalarMult(pkA, new(big.Int).Mul(big.NewInt(j), t))
+			kps[j-1] = kp.Add(kp, temp.Neg(temp))
+		}
+		curve.NewPoint(curve.TypeG1).ScalarMult(L, t)
+	}
+}
+
+func benchCalculateKeyPointsWorkers(b *testing.B, count int64, workers int) {
+	params := &ot.Params{Workers: workers}
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		Y, L, pkA := randPoint(curve.TypeG1), randPoint(curve.TypeG1), randPoint(curve.TypeG1)
+		b.StartTimer()
+		if _, _, err := params.CalculateKeyPoints(Y, L, pkA, count); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchAdaptiveSetup(b *testing.B, count int64) {
+	for i := 0; i < b.N; i++ {
+		if _, _, err := ot.SetupAdaptive(count); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchAdaptiveAnswer(b *testing.B, count int64) {
+	sender, _, err := ot.SetupAdaptive(count)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		query, _, err := ot.QueryAdaptive(sender.Transcript, rand.Int63n(count)+1)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		if _, err = sender.Answer(query); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func benchEncrypt(b *testing.B, size int64) {
 	for i := 0; i < b.N; i++ {
 		b.StopTimer()
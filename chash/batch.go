@@ -0,0 +1,110 @@
+package chash
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// A chameleon hash is a digest of its hash point, and digests do not combine,
+// so batches are verified on the hash points. A verifier holding digests gets
+// the hash points from the holder of the blocks, e.g. by Hasher.HashPoint, and
+// BatchVerifyDigests checks them against the digests, one SHA256 each.
+
+// batchCoefficientSize is the size of the random coefficients, a batch with an
+// invalid item passes with probability 2^-64.
+const batchCoefficientSize = 8
+
+var (
+	// ErrLengthMismatch occurs when the digests, targets, randomness and
+	// messages of a batch differ in length.
+	ErrLengthMismatch = errors.New("batch length mismatch")
+
+	// ErrInvalidPublicKey occurs when the public key of a batch is not a G1 point.
+	ErrInvalidPublicKey = errors.New("chameleon hash public key is not a G1 point")
+)
+
+// BatchVerify verifies that targets[i] = ComputeHashPoint(Y, Rs[i], ms[i]) for
+// every i, with random coefficients c_i and a single pairing, as Y is common:
+//
+//	sum(c_i * T_i) = sum(c_i * m_i) * e(G1, G2) + e(Y, sum(c_i * R_i))
+//
+// If the batch fails, every item is verified on its own and the indexes of the
+// invalid ones are returned.
+func BatchVerify(targets []curve.Point, Y curve.Point, Rs []curve.Point, ms []*big.Int) (failed []int, err error) {
+	if len(targets) != len(Rs) || len(targets) != len(ms) {
+		return nil, ErrLengthMismatch
+	}
+	if pk, ok := Y.(*curve.G1); !ok || pk == nil {
+		return nil, ErrInvalidPublicKey
+	}
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	ok, err := batchVerify(rand.Reader, targets, Y, Rs, ms)
+	if err != nil || ok {
+		return nil, err
+	}
+	for i := range targets {
+		if !verifyPoint(targets[i], Y, Rs[i], ms[i]) {
+			failed = append(failed, i)
+		}
+	}
+	return failed, nil
+}
+
+// BatchVerifyDigests verifies that digests[i] is the chameleon hash of
+// ComputeHashPoint(Y, Rs[i], ms[i]) for every i, like BatchVerify on the hash
+// points targets, which must match the digests. The indexes of the items
+// whose hash point does not match its digest, or does not verify, are
+// returned.
+func BatchVerifyDigests(digests []ChameleonHash, targets []curve.Point, Y curve.Point, Rs []curve.Point, ms []*big.Int) (failed []int, err error) {
+	if len(digests) != len(targets) {
+		return nil, ErrLengthMismatch
+	}
+	// the hash point of a mismatched digest is dropped, so that its item fails
+	checked := make([]curve.Point, len(targets))
+	for i := range targets {
+		if isGT(targets[i]) && NewChameleonHash(targets[i]) == digests[i] {
+			checked[i] = targets[i]
+		}
+	}
+	return BatchVerify(checked, Y, Rs, ms)
+}
+
+func batchVerify(r io.Reader, targets []curve.Point, Y curve.Point, Rs []curve.Point, ms []*big.Int) (bool, error) {
+	var buf [batchCoefficientSize]byte
+	left := curve.NewPoint(curve.TypeGT)
+	sumR := curve.NewPoint(curve.TypeG2)
+	sumM := new(big.Int)
+	for i := range targets {
+		if !isGT(targets[i]) || !isG2(Rs[i]) || ms[i] == nil {
+			return false, nil
+		}
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return false, err
+		}
+		c := new(big.Int).SetBytes(buf[:])
+		if i == 0 {
+			left.ScalarMult(targets[i], c)
+			sumR.ScalarMult(Rs[i], c)
+		} else {
+			left.Add(left, curve.NewPoint(curve.TypeGT).ScalarMult(targets[i], c))
+			sumR.Add(sumR, curve.NewPoint(curve.TypeG2).ScalarMult(Rs[i], c))
+		}
+		sumM.Add(sumM, c.Mul(c, ms[i]))
+	}
+	right := curve.NewPoint(curve.TypeGT).ScalarBaseMult(sumM.Mod(sumM, curve.Order))
+	right.Add(right, curve.Pair(Y.(*curve.G1), sumR.(*curve.G2)))
+	return string(left.Marshal()) == string(right.Marshal()), nil
+}
+
+func verifyPoint(target, Y, R curve.Point, m *big.Int) bool {
+	if !isGT(target) || !isG2(R) || m == nil {
+		return false
+	}
+	return string(ComputeHashPoint(Y, R, m).Marshal()) == string(target.Marshal())
+}
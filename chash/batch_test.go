@@ -0,0 +1,134 @@
+package chash_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/clarenous/proxyot/chash"
+	"github.com/clarenous/proxyot/curve"
+)
+
+func generateBatch(t *testing.T, count int) (targets []curve.Point, Y curve.Point, Rs []curve.Point, ms []*big.Int) {
+	_, Y, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets, Rs, ms = make([]curve.Point, count), make([]curve.Point, count), make([]*big.Int, count)
+	for i := range targets {
+		if _, Rs[i], err = curve.NewRandomPoint(curve.TypeG2, rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		if ms[i], err = curve.RandomFieldElement(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		targets[i] = chash.ComputeHashPoint(Y, Rs[i], ms[i])
+	}
+	return
+}
+
+func TestBatchVerify(t *testing.T) {
+	targets, Y, Rs, ms := generateBatch(t, 20)
+	if chash.NewChameleonHash(targets[0]) != chash.ComputeHash(Y, Rs[0], ms[0]) {
+		t.Fatal("hash point does not match chameleon hash")
+	}
+
+	failed, err := chash.BatchVerify(targets, Y, Rs, ms)
+	if err != nil || failed != nil {
+		t.Fatalf("valid batch: failed %v, err %v", failed, err)
+	}
+
+	// invalid items are reported
+	ms[3] = new(big.Int).Add(ms[3], big.NewInt(1))
+	Rs[11] = Rs[12]
+	failed, err = chash.BatchVerify(targets, Y, Rs, ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{3, 11}; !reflect.DeepEqual(failed, want) {
+		t.Errorf("want failed %v, got %v", want, failed)
+	}
+
+	if _, err = chash.BatchVerify(targets, Y, Rs[1:], ms); !errors.Is(err, chash.ErrLengthMismatch) {
+		t.Errorf("want ErrLengthMismatch, got %v", err)
+	}
+	if _, err = chash.BatchVerify(targets, Rs[0], Rs, ms); !errors.Is(err, chash.ErrInvalidPublicKey) {
+		t.Errorf("G2 public key: want ErrInvalidPublicKey, got %v", err)
+	}
+
+	// typed-nil points and nil messages fail, they do not panic
+	targets, Y, Rs, ms = generateBatch(t, 6)
+	targets[1] = (*curve.GT)(nil)
+	Rs[2] = (*curve.G2)(nil)
+	ms[4] = nil
+	failed, err = chash.BatchVerify(targets, Y, Rs, ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 2, 4}; !reflect.DeepEqual(failed, want) {
+		t.Errorf("want failed %v, got %v", want, failed)
+	}
+}
+
+func TestBatchVerifyDigests(t *testing.T) {
+	td, err := chash.GenerateTrapdoor(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hasher := chash.NewHasher(td.PublicKey())
+	blocks := [][]byte{[]byte("block 0"), []byte("block 1"), []byte("block 2"), []byte("block 3")}
+	digests := make([]chash.ChameleonHash, len(blocks))
+	targets, Rs, ms := make([]curve.Point, len(blocks)), make([]curve.Point, len(blocks)), make([]*big.Int, len(blocks))
+	for i := range blocks {
+		rd, err := chash.NewRandomness(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		digests[i] = hasher.Hash(blocks[i], rd)
+		targets[i] = hasher.HashPoint(blocks[i], rd)
+		Rs[i], ms[i] = rd.R, chash.MessageToFieldElement(blocks[i])
+	}
+	failed, err := chash.BatchVerifyDigests(digests, targets, td.PublicKey().Y, Rs, ms)
+	if err != nil || failed != nil {
+		t.Fatalf("valid batch: failed %v, err %v", failed, err)
+	}
+
+	// a hash point that is not the one of its digest fails, even if it verifies
+	targets[1], ms[1], Rs[1] = targets[2], ms[2], Rs[2]
+	digests[3][0] ^= 0xff
+	if failed, err = chash.BatchVerifyDigests(digests, targets, td.PublicKey().Y, Rs, ms); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 3}; !reflect.DeepEqual(failed, want) {
+		t.Errorf("want failed %v, got %v", want, failed)
+	}
+	if _, err = chash.BatchVerifyDigests(digests[1:], targets, td.PublicKey().Y, Rs, ms); !errors.Is(err, chash.ErrLengthMismatch) {
+		t.Errorf("want ErrLengthMismatch, got %v", err)
+	}
+}
+
+func TestBatchVerifyTime(t *testing.T) {
+	var count = 1000
+	targets, Y, Rs, ms := generateBatch(t, count)
+	hashes := make([]chash.ChameleonHash, count)
+	for i := range hashes {
+		hashes[i] = chash.NewChameleonHash(targets[i])
+	}
+
+	start := time.Now()
+	for i := range hashes {
+		if !chash.Verify(hashes[i], Y, Rs[i], ms[i]) {
+			t.Fatal("verify failed")
+		}
+	}
+	t.Log(count, "items verified one by one, time spent:", time.Since(start).Seconds())
+
+	start = time.Now()
+	if failed, err := chash.BatchVerify(targets, Y, Rs, ms); err != nil || failed != nil {
+		t.Fatalf("failed %v, err %v", failed, err)
+	}
+	t.Log(count, "items verified in batch, time spent:", time.Since(start).Seconds())
+}
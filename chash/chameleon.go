@@ -20,12 +20,22 @@ func (ch ChameleonHash) String() string {
 	return hex.EncodeToString(ch[:])
 }
 
+// NewChameleonHash returns the chameleon hash of the hash point h.
+func NewChameleonHash(h curve.Point) ChameleonHash {
+	return sha256.Sum256(h.Marshal())
+}
+
 // ComputeHash computes the chameleon hash.
 func ComputeHash(Y, R curve.Point, m *big.Int) ChameleonHash {
+	return NewChameleonHash(ComputeHashPoint(Y, R, m))
+}
+
+// ComputeHashPoint computes the hash point m * e(G1, G2) + e(Y, R), of which
+// the chameleon hash is the digest.
+func ComputeHashPoint(Y, R curve.Point, m *big.Int) curve.Point {
 	Qm := curve.NewPoint(curve.TypeGT).ScalarBaseMult(m)
 	pairedYR := curve.Pair(Y.(*curve.G1), R.(*curve.G2))
-	h := curve.NewPoint(curve.TypeGT).Add(Qm, pairedYR)
-	return sha256.Sum256(h.Marshal())
+	return curve.NewPoint(curve.TypeGT).Add(Qm, pairedYR)
 }
 
 // Verify verifies a given target with original params.
@@ -45,3 +55,18 @@ func ComputeCollision(Y curve.Point, x, r, m, mp, q *big.Int) (ChameleonHash, *b
 	ch := ComputeHash(Y, Rp, mp)
 	return ch, rp, Rp
 }
+
+func isG1(p curve.Point) bool {
+	g, ok := p.(*curve.G1)
+	return ok && g != nil
+}
+
+func isG2(p curve.Point) bool {
+	g, ok := p.(*curve.G2)
+	return ok && g != nil
+}
+
+func isGT(p curve.Point) bool {
+	g, ok := p.(*curve.GT)
+	return ok && g != nil
+}
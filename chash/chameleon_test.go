@@ -88,3 +88,25 @@ func generateParams() (m, mp, x, r *big.Int, Y, R curve.Point, err error) {
 	}
 	return
 }
+
+// Two collisions of the same hash publish rp1, rp2 with
+// m1 + x * rp1 = m2 + x * rp2, which gives x = (m1 - m2) / (rp2 - rp1).
+func TestComputeCollisionExposesKey(t *testing.T) {
+	m, m1, x, r, Y, _, err := generateParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m2, err := curve.RandomFieldElement(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, rp1, _ := chash.ComputeCollision(Y, x, r, m, m1, curve.Order)
+	_, rp2, _ := chash.ComputeCollision(Y, x, r, m, m2, curve.Order)
+
+	recovered := new(big.Int).Sub(rp2, rp1)
+	recovered.ModInverse(recovered.Mod(recovered, curve.Order), curve.Order)
+	recovered.Mul(recovered, new(big.Int).Sub(m1, m2)).Mod(recovered, curve.Order)
+	if recovered.Cmp(x) != 0 {
+		t.Fatal("expected the trapdoor to be recovered from two collisions")
+	}
+}
@@ -0,0 +1,209 @@
+package chash
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+const scalarSize = 32
+
+var (
+	// ErrNoTrapdoor occurs when a hasher without trapdoor computes a collision.
+	ErrNoTrapdoor = errors.New("hasher has no trapdoor")
+
+	// ErrInvalidTrapdoor occurs when a trapdoor does not decode.
+	ErrInvalidTrapdoor = errors.New("invalid chameleon hash trapdoor")
+
+	// ErrInvalidRandomness occurs when a randomness does not decode, or its
+	// scalar does not match its point.
+	ErrInvalidRandomness = errors.New("invalid chameleon hash randomness")
+)
+
+// Trapdoor is the secret key x of the chameleon hash.
+type Trapdoor struct {
+	x *big.Int
+}
+
+// GenerateTrapdoor generates a trapdoor with randomness read from r, or from
+// crypto/rand.Reader if r is nil.
+func GenerateTrapdoor(r io.Reader) (*Trapdoor, error) {
+	x, err := curve.RandomFieldElement(random(r))
+	if err != nil {
+		return nil, err
+	}
+	return &Trapdoor{x: x}, nil
+}
+
+// PublicKey returns the public key of the trapdoor.
+func (td *Trapdoor) PublicKey() *PublicKey {
+	return &PublicKey{Y: curve.NewPoint(curve.TypeG1).ScalarBaseMult(td.x)}
+}
+
+// Marshal encodes the trapdoor as a 32-byte big endian scalar.
+func (td *Trapdoor) Marshal() []byte {
+	return marshalScalar(td.x)
+}
+
+// Unmarshal decodes a trapdoor encoded by Marshal.
+func (td *Trapdoor) Unmarshal(m []byte) error {
+	x := new(big.Int).SetBytes(m)
+	if len(m) != scalarSize || x.Sign() == 0 || x.Cmp(curve.Order) >= 0 {
+		return ErrInvalidTrapdoor
+	}
+	td.x = x
+	return nil
+}
+
+// PublicKey is the public key Y = x * G1 of the chameleon hash.
+type PublicKey struct {
+	Y curve.Point
+}
+
+// Marshal encodes the public key as the G1 point Y.
+func (pk *PublicKey) Marshal() []byte {
+	return pk.Y.Marshal()
+}
+
+// Unmarshal decodes a public key encoded by Marshal.
+func (pk *PublicKey) Unmarshal(m []byte) error {
+	Y := curve.NewPoint(curve.TypeG1)
+	if _, err := Y.Unmarshal(m); err != nil {
+		return err
+	}
+	pk.Y = Y
+	return nil
+}
+
+// Randomness is the randomness R = r * G2 of a chameleon hash. The holder of
+// the trapdoor keeps r to compute collisions. Others need R only, see Public:
+// r and the r' of a collision together reveal the trapdoor.
+type Randomness struct {
+	r *big.Int // nil if public
+	R curve.Point
+}
+
+// NewRandomness generates a randomness read from r, or from
+// crypto/rand.Reader if r is nil.
+func NewRandomness(r io.Reader) (*Randomness, error) {
+	k, R, err := curve.NewRandomPoint(curve.TypeG2, random(r))
+	if err != nil {
+		return nil, err
+	}
+	return &Randomness{r: k, R: R}, nil
+}
+
+// Public returns the randomness without r, to be published.
+func (rd *Randomness) Public() *Randomness {
+	return &Randomness{R: rd.R}
+}
+
+// IsPublic reports whether the randomness lacks r.
+func (rd *Randomness) IsPublic() bool {
+	return rd.r == nil
+}
+
+// Marshal encodes the randomness as R(128), followed by r(32) unless public.
+func (rd *Randomness) Marshal() []byte {
+	buf := rd.R.Marshal()
+	if rd.r != nil {
+		buf = append(buf, marshalScalar(rd.r)...)
+	}
+	return buf
+}
+
+// Unmarshal decodes a randomness encoded by Marshal, checking R = r * G2 if r
+// is present.
+func (rd *Randomness) Unmarshal(m []byte) (err error) {
+	R := curve.NewPoint(curve.TypeG2)
+	if m, err = R.Unmarshal(m); err != nil {
+		return err
+	}
+	var r *big.Int
+	switch len(m) {
+	case 0:
+	case scalarSize:
+		r = new(big.Int).SetBytes(m)
+		if r.Cmp(curve.Order) >= 0 || string(curve.NewPoint(curve.TypeG2).ScalarBaseMult(r).Marshal()) != string(R.Marshal()) {
+			return ErrInvalidRandomness
+		}
+	default:
+		return ErrInvalidRandomness
+	}
+	rd.r, rd.R = r, R
+	return nil
+}
+
+// Hasher computes chameleon hashes of byte messages, mapped to field elements
+// by MessageToFieldElement.
+type Hasher struct {
+	pk *PublicKey
+	td *Trapdoor // nil if the hasher only hashes and verifies
+}
+
+// NewHasher creates a hasher that hashes and verifies.
+func NewHasher(pk *PublicKey) *Hasher {
+	return &Hasher{pk: pk}
+}
+
+// NewTrapdoorHasher creates a hasher that also computes collisions.
+func NewTrapdoorHasher(td *Trapdoor) *Hasher {
+	return &Hasher{pk: td.PublicKey(), td: td}
+}
+
+// PublicKey returns the public key of the hasher.
+func (h *Hasher) PublicKey() *PublicKey {
+	return h.pk
+}
+
+// Hash computes the chameleon hash of msg.
+func (h *Hasher) Hash(msg []byte, rd *Randomness) ChameleonHash {
+	return ComputeHash(h.pk.Y, rd.R, MessageToFieldElement(msg))
+}
+
+// HashPoint computes the hash point of msg, of which Hash is the digest, for
+// BatchVerifyDigests.
+func (h *Hasher) HashPoint(msg []byte, rd *Randomness) curve.Point {
+	return ComputeHashPoint(h.pk.Y, rd.R, MessageToFieldElement(msg))
+}
+
+// Verify verifies that target is the chameleon hash of msg.
+func (h *Hasher) Verify(target ChameleonHash, msg []byte, rd *Randomness) bool {
+	return Verify(target, h.pk.Y, rd.R, MessageToFieldElement(msg))
+}
+
+// Collide computes the randomness under which newMsg has the same chameleon
+// hash as msg under rd, and the chameleon hash of newMsg under it.
+func (h *Hasher) Collide(rd *Randomness, msg, newMsg []byte) (ChameleonHash, *Randomness, error) {
+	if h.td == nil {
+		return ChameleonHash{}, nil, ErrNoTrapdoor
+	}
+	if rd.r == nil {
+		return ChameleonHash{}, nil, ErrInvalidRandomness
+	}
+	ch, rp, Rp := ComputeCollision(h.pk.Y, h.td.x, rd.r, MessageToFieldElement(msg), MessageToFieldElement(newMsg), curve.Order)
+	return ch, &Randomness{r: rp.Mod(rp, curve.Order), R: Rp}, nil
+}
+
+// MessageToFieldElement maps msg to SHA256(msg) mod curve.Order.
+func MessageToFieldElement(msg []byte) *big.Int {
+	sum := sha256.Sum256(msg)
+	m := new(big.Int).SetBytes(sum[:])
+	return m.Mod(m, curve.Order)
+}
+
+func marshalScalar(k *big.Int) []byte {
+	b := k.Bytes()
+	return append(make([]byte, scalarSize-len(b)), b...)
+}
+
+func random(r io.Reader) io.Reader {
+	if r == nil {
+		return rand.Reader
+	}
+	return r
+}
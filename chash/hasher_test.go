@@ -0,0 +1,72 @@
+package chash_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/clarenous/proxyot/chash"
+)
+
+func TestHasher(t *testing.T) {
+	td, err := chash.GenerateTrapdoor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rd, err := chash.NewRandomness(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, newMsg := []byte("block data"), []byte("redacted block data")
+
+	owner := chash.NewTrapdoorHasher(td)
+	ch := owner.Hash(msg, rd)
+
+	// a verifier decodes the public key and the public randomness
+	pk := &chash.PublicKey{}
+	if err = pk.Unmarshal(td.PublicKey().Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	public := &chash.Randomness{}
+	if err = public.Unmarshal(rd.Public().Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	if !public.IsPublic() {
+		t.Error("public randomness carries r")
+	}
+	verifier := chash.NewHasher(pk)
+	if !verifier.Verify(ch, msg, public) || verifier.Verify(ch, newMsg, public) {
+		t.Fatal("verify failed")
+	}
+
+	// the owner decodes its trapdoor and randomness to collide
+	decodedTd := &chash.Trapdoor{}
+	if err = decodedTd.Unmarshal(td.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	decodedRd := &chash.Randomness{}
+	if err = decodedRd.Unmarshal(rd.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	newH, newRd, err := chash.NewTrapdoorHasher(decodedTd).Collide(decodedRd, msg, newMsg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !newH.Equals(ch) || !verifier.Verify(ch, newMsg, newRd.Public()) {
+		t.Error("collision failed")
+	}
+	if err = (&chash.Randomness{}).Unmarshal(newRd.Marshal()); err != nil {
+		t.Errorf("collision randomness: %v", err)
+	}
+
+	if _, _, err = verifier.Collide(rd, msg, newMsg); !errors.Is(err, chash.ErrNoTrapdoor) {
+		t.Errorf("want ErrNoTrapdoor, got %v", err)
+	}
+	if _, _, err = owner.Collide(public, msg, newMsg); !errors.Is(err, chash.ErrInvalidRandomness) {
+		t.Errorf("public randomness: want ErrInvalidRandomness, got %v", err)
+	}
+	tampered := rd.Marshal()
+	tampered[len(tampered)-1] ^= 1
+	if err = (&chash.Randomness{}).Unmarshal(tampered); !errors.Is(err, chash.ErrInvalidRandomness) {
+		t.Errorf("tampered r: want ErrInvalidRandomness, got %v", err)
+	}
+}
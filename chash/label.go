@@ -0,0 +1,69 @@
+package chash
+
+import (
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// The labeled chameleon hash is key-exposure free, after the ID-based scheme
+// of Ateniese and de Medeiros, "On the Key Exposure Problem in Chameleon
+// Hashes". With public key Y = x * G2 and Q = H(label) hashed to G1:
+//
+//   CH(label, m, R) = e(R, G2) + m * e(Q, Y), R in G1
+//
+// A collision is R' = R + (m - m') * S, where S = x * Q is the trapdoor of the
+// label. Unlike ComputeCollision, which reveals the scalar randomness and so
+// x to whoever sees two collisions, a collision here reveals at most S: it
+// lets anyone find further collisions under the same label, but none under
+// another one, and x stays secret. Labels should therefore be used once, e.g.
+// one per block version.
+
+const domainLabel = "proxyot/chash/label"
+
+// NewLabelPublicKey returns the public key Y = x * G2 of the labeled hash.
+func NewLabelPublicKey(x *big.Int) curve.Point {
+	return curve.NewPoint(curve.TypeG2).ScalarBaseMult(x)
+}
+
+// ExtractLabelTrapdoor returns the trapdoor S = x * H(label) of label, with
+// which ComputeLabeledCollision finds collisions under label only.
+func ExtractLabelTrapdoor(x *big.Int, label []byte) curve.Point {
+	return curve.NewPoint(curve.TypeG1).ScalarMult(hashLabel(label), x)
+}
+
+// ComputeLabeledHash computes the labeled chameleon hash.
+func ComputeLabeledHash(Y curve.Point, label []byte, R curve.Point, m *big.Int) ChameleonHash {
+	pairedRG := curve.Pair(R.(*curve.G1), g2Generator())
+	pairedQY := curve.Pair(hashLabel(label).(*curve.G1), Y.(*curve.G2))
+	Qm := curve.NewPoint(curve.TypeGT).ScalarMult(pairedQY, m)
+	h := curve.NewPoint(curve.TypeGT).Add(pairedRG, Qm)
+	return sha256.Sum256(h.Marshal())
+}
+
+// VerifyLabeled verifies a given target with original params.
+func VerifyLabeled(target ChameleonHash, Y curve.Point, label []byte, R curve.Point, m *big.Int) bool {
+	computed := ComputeLabeledHash(Y, label, R, m)
+	return computed.Equals(target)
+}
+
+// ComputeLabeledCollision computes an instance of collision of the labeled
+// chameleon hash, given the trapdoor S of its label.
+func ComputeLabeledCollision(Y, S curve.Point, label []byte, R curve.Point, m, mp *big.Int) (ChameleonHash, curve.Point) {
+	// R' = R + (m - mp) * S
+	delta := new(big.Int).Sub(m, mp)
+	delta.Mod(delta, curve.Order)
+	Rp := curve.NewPoint(curve.TypeG1).ScalarMult(S, delta)
+	Rp.Add(Rp, R)
+	ch := ComputeLabeledHash(Y, label, Rp, mp)
+	return ch, Rp
+}
+
+func hashLabel(label []byte) curve.Point {
+	return curve.HashToG1(label, []byte(domainLabel))
+}
+
+func g2Generator() *curve.G2 {
+	return curve.NewPoint(curve.TypeG2).ScalarBaseMult(big.NewInt(1)).(*curve.G2)
+}
@@ -0,0 +1,76 @@
+package chash_test
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/clarenous/proxyot/chash"
+	"github.com/clarenous/proxyot/curve"
+)
+
+func TestLabeledCollision(t *testing.T) {
+	m, mp, x, _, _, _, err := generateParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, R, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Y := chash.NewLabelPublicKey(x)
+	label := []byte("block-1")
+
+	ch := chash.ComputeLabeledHash(Y, label, R, m)
+	if !chash.VerifyLabeled(ch, Y, label, R, m) {
+		t.Fatal("verify failed")
+	}
+	if chash.VerifyLabeled(ch, Y, []byte("block-2"), R, m) {
+		t.Error("verified under another label")
+	}
+
+	S := chash.ExtractLabelTrapdoor(x, label)
+	newH, Rp := chash.ComputeLabeledCollision(Y, S, label, R, m, mp)
+	if !newH.Equals(ch) || !chash.VerifyLabeled(ch, Y, label, Rp, mp) {
+		t.Error("collision failed")
+	}
+}
+
+// A published collision exposes the trapdoor of its label, which finds no
+// collision under another label, and not x.
+func TestLabeledCollisionKeepsKey(t *testing.T) {
+	m, m1, x, _, _, _, err := generateParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, R, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Y := chash.NewLabelPublicKey(x)
+	label, other := []byte("block-1"), []byte("block-2")
+	ch := chash.ComputeLabeledHash(Y, label, R, m)
+	_, R1 := chash.ComputeLabeledCollision(Y, chash.ExtractLabelTrapdoor(x, label), label, R, m, m1)
+
+	// an observer recovers S = (R1 - R) / (m - m1)
+	delta := new(big.Int).Sub(m, m1)
+	delta.ModInverse(delta.Mod(delta, curve.Order), curve.Order)
+	diff := curve.NewPoint(curve.TypeG1).Neg(R)
+	diff.Add(diff, R1)
+	S := curve.NewPoint(curve.TypeG1).ScalarMult(diff, delta)
+
+	// S finds further collisions under the label
+	m2, err := curve.RandomFieldElement(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, _ := chash.ComputeLabeledCollision(Y, S, label, R, m, m2); !h.Equals(ch) {
+		t.Error("recovered label trapdoor failed under its label")
+	}
+
+	// but none under another label
+	otherH := chash.ComputeLabeledHash(Y, other, R, m)
+	if h, _ := chash.ComputeLabeledCollision(Y, S, other, R, m, m2); h.Equals(otherH) {
+		t.Error("label trapdoor found a collision under another label")
+	}
+}
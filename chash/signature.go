@@ -0,0 +1,107 @@
+package chash
+
+import (
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// Chameleon signatures follow Krawczyk and Rabin, "Chameleon Signatures". The
+// signer hashes the message with the chameleon hash of the recipient, and
+// signs the recipient key and the hash with a BLS signature:
+//
+//   sigma = sk * H(Y || CH(m, R)), verified by e(sigma, G2) = e(H(Y || CH), sk * G2)
+//
+// The recipient is convinced, as only the signer computes sigma. A third party
+// is not: the recipient holds the trapdoor of Y, and opens the same sigma to
+// any message by Forge, so the signature does not transfer. Should the
+// recipient present a forgery, the signer shows the original message and
+// randomness; the two collide, which reveals the trapdoor and the forgery.
+
+const domainSignature = "proxyot/chash/signature"
+
+var (
+	// ErrInvalidSignature occurs when a chameleon signature does not decode.
+	ErrInvalidSignature = errors.New("invalid chameleon signature")
+)
+
+// ChameleonSignature is a signature on a message, under the chameleon key of
+// its recipient.
+type ChameleonSignature struct {
+	Sigma      curve.Point // G1 BLS signature
+	Randomness *Randomness // with r, for the recipient to forge
+}
+
+// Sign signs msg for the recipient with chameleon key recipient, by the
+// secret key sk whose G2 public key sk * G2 verifies it. Randomness is read
+// from r, or from crypto/rand.Reader if r is nil.
+func Sign(sk *big.Int, recipient *PublicKey, msg []byte, r io.Reader) (*ChameleonSignature, error) {
+	rd, err := NewRandomness(r)
+	if err != nil {
+		return nil, err
+	}
+	ch := ComputeHash(recipient.Y, rd.R, MessageToFieldElement(msg))
+	return &ChameleonSignature{
+		Sigma:      curve.NewPoint(curve.TypeG1).ScalarMult(hashSigned(recipient, ch), sk),
+		Randomness: rd,
+	}, nil
+}
+
+// Verify verifies the signature on msg by the signer of G2 public key signer,
+// for the recipient with chameleon key recipient.
+func (sig *ChameleonSignature) Verify(signer curve.Point, recipient *PublicKey, msg []byte) bool {
+	if !sig.complete() || !isG2(signer) || recipient == nil || !isG1(recipient.Y) {
+		return false
+	}
+	ch := ComputeHash(recipient.Y, sig.Randomness.R, MessageToFieldElement(msg))
+	left := curve.Pair(sig.Sigma.(*curve.G1), g2Generator())
+	right := curve.Pair(hashSigned(recipient, ch).(*curve.G1), signer.(*curve.G2))
+	return string(left.Marshal()) == string(right.Marshal())
+}
+
+// Forge opens the signature sig on msg to newMsg, by the trapdoor td of the
+// recipient. The forgery verifies like the original.
+func Forge(td *Trapdoor, sig *ChameleonSignature, msg, newMsg []byte) (*ChameleonSignature, error) {
+	if sig.Randomness == nil || sig.Randomness.r == nil {
+		return nil, ErrInvalidRandomness
+	}
+	_, rp, Rp := ComputeCollision(td.PublicKey().Y, td.x, sig.Randomness.r, MessageToFieldElement(msg), MessageToFieldElement(newMsg), curve.Order)
+	return &ChameleonSignature{
+		Sigma:      sig.Sigma,
+		Randomness: &Randomness{r: rp.Mod(rp, curve.Order), R: Rp},
+	}, nil
+}
+
+// Marshal encodes the signature as sigma(64) || randomness, see
+// Randomness.Marshal. It returns nil if sigma or the randomness is missing.
+func (sig *ChameleonSignature) Marshal() []byte {
+	if !sig.complete() {
+		return nil
+	}
+	return append(sig.Sigma.Marshal(), sig.Randomness.Marshal()...)
+}
+
+// Unmarshal decodes a signature encoded by Marshal.
+func (sig *ChameleonSignature) Unmarshal(m []byte) (err error) {
+	sigma := curve.NewPoint(curve.TypeG1)
+	if m, err = sigma.Unmarshal(m); err != nil {
+		return ErrInvalidSignature
+	}
+	rd := &Randomness{}
+	if err = rd.Unmarshal(m); err != nil {
+		return err
+	}
+	sig.Sigma, sig.Randomness = sigma, rd
+	return nil
+}
+
+// complete reports whether sig has a G1 sigma and a G2 randomness.
+func (sig *ChameleonSignature) complete() bool {
+	return sig != nil && isG1(sig.Sigma) && sig.Randomness != nil && isG2(sig.Randomness.R)
+}
+
+func hashSigned(recipient *PublicKey, ch ChameleonHash) curve.Point {
+	return curve.HashToG1(append(recipient.Marshal(), ch[:]...), []byte(domainSignature))
+}
@@ -0,0 +1,83 @@
+package chash_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/clarenous/proxyot/chash"
+	"github.com/clarenous/proxyot/curve"
+)
+
+func TestChameleonSignature(t *testing.T) {
+	sk, signer, err := curve.NewRandomPoint(curve.TypeG2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	td, err := chash.GenerateTrapdoor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := chash.GenerateTrapdoor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := td.PublicKey()
+	manifest, forged := []byte("file set manifest"), []byte("forged manifest")
+
+	sig, err := chash.Sign(sk, recipient, manifest, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &chash.ChameleonSignature{}
+	if err = decoded.Unmarshal(sig.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Verify(signer, recipient, manifest) {
+		t.Fatal("verify failed")
+	}
+	if decoded.Verify(signer, recipient, forged) {
+		t.Error("verified another message")
+	}
+	if decoded.Verify(signer, other.PublicKey(), manifest) {
+		t.Error("verified for another recipient")
+	}
+
+	// the recipient opens the same sigma to any message
+	forgery, err := chash.Forge(td, decoded, manifest, forged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if forgery.Sigma.String() != sig.Sigma.String() || !forgery.Verify(signer, recipient, forged) {
+		t.Error("forgery failed")
+	}
+
+	// but can not without the trapdoor
+	if forgery, err = chash.Forge(other, decoded, manifest, forged); err != nil {
+		t.Fatal(err)
+	}
+	if forgery.Verify(signer, recipient, forged) {
+		t.Error("forged without the trapdoor")
+	}
+
+	// missing and typed-nil inputs fail, they do not panic
+	if decoded.Verify(signer, nil, manifest) || decoded.Verify(signer, &chash.PublicKey{Y: (*curve.G1)(nil)}, manifest) {
+		t.Error("verified for a nil recipient")
+	}
+	if decoded.Verify((*curve.G2)(nil), recipient, manifest) {
+		t.Error("verified by a nil signer")
+	}
+	incomplete := []*chash.ChameleonSignature{
+		{Randomness: sig.Randomness},
+		{Sigma: (*curve.G1)(nil), Randomness: sig.Randomness},
+		{Sigma: sig.Sigma},
+		{Sigma: sig.Sigma, Randomness: &chash.Randomness{R: (*curve.G2)(nil)}},
+	}
+	for i, sig := range incomplete {
+		if sig.Verify(signer, recipient, manifest) {
+			t.Errorf("incomplete signature %d verified", i)
+		}
+		if sig.Marshal() != nil {
+			t.Errorf("incomplete signature %d marshaled", i)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package curve
 import (
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"math/big"
@@ -62,7 +63,13 @@ func RandomFieldElement(r io.Reader) (*big.Int, error) {
 // (2) Calculate k = BigInt(h) % Order
 // (3) If k == 0, calculate h = SHA256(h), then jump to (2)
 func DeriveFieldElementFromPoint(point Point) *big.Int {
-	var h = sha256.Sum256(point.Marshal())
+	return DeriveFieldElementFromBytes(point.Marshal())
+}
+
+// DeriveFieldElementFromBytes derives a field element k from data by the rules
+// of DeriveFieldElementFromPoint, with point.Marshal() replaced by data.
+func DeriveFieldElementFromBytes(data []byte) *big.Int {
+	var h = sha256.Sum256(data)
 	var k = new(big.Int)
 	for {
 		k.SetBytes(h[:])
@@ -74,3 +81,23 @@ func DeriveFieldElementFromPoint(point Point) *big.Int {
 	}
 	return k
 }
+
+// DeriveChallenge derives a non-interactive (Fiat-Shamir) challenge from a
+// domain tag and the parts of a proof transcript. Every part is prefixed with
+// its length, so that different transcripts never hash the same data.
+func DeriveChallenge(domain string, transcript ...[]byte) *big.Int {
+	var data []byte
+	var size [4]byte
+	for _, part := range append([][]byte{[]byte(domain)}, transcript...) {
+		binary.BigEndian.PutUint32(size[:], uint32(len(part)))
+		data = append(data, size[:]...)
+		data = append(data, part...)
+	}
+	return DeriveFieldElementFromBytes(data)
+}
+
+// HashToG1 hashes msg to a G1 point whose discrete logarithm is unknown, with
+// domain separation tag dst.
+func HashToG1(msg, dst []byte) Point {
+	return (*G1)(bn256.HashG1(msg, dst))
+}
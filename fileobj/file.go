@@ -16,6 +16,21 @@ var (
 	ErrWrongBlockSize  = errors.New("wrong data block size")
 )
 
+// Object is a file of fixed-size blocks, implemented by FileObj and MemFileObj.
+type Object interface {
+	FileSize() int64
+	BlockSize() int64
+	BlockCount() int64
+	GetBlock(blk int64) ([]byte, error)
+	SetBlock(blk int64, data []byte) error
+	Close() error
+}
+
+var (
+	_ Object = (*FileObj)(nil)
+	_ Object = (*MemFileObj)(nil)
+)
+
 type FileObj struct {
 	f          *os.File
 	fileSize   int64
@@ -23,10 +38,19 @@ type FileObj struct {
 	blockCount int64
 }
 
-// TODO: support readonly/readwrite mode
+// NewFileObj opens filename read-only.
 func NewFileObj(filename string, blockSize int64) (obj *FileObj, err error) {
+	return openFileObj(filename, blockSize, os.O_RDONLY)
+}
+
+// NewWritableFileObj opens filename read-write, for SetBlock.
+func NewWritableFileObj(filename string, blockSize int64) (obj *FileObj, err error) {
+	return openFileObj(filename, blockSize, os.O_RDWR)
+}
+
+func openFileObj(filename string, blockSize int64, flag int) (obj *FileObj, err error) {
 	var f *os.File
-	if f, err = os.Open(filename); err != nil {
+	if f, err = os.OpenFile(filename, flag, 0); err != nil {
 		return nil, err
 	}
 	var fi os.FileInfo
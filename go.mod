@@ -11,6 +11,6 @@ require (
 	github.com/libp2p/go-libp2p-core v0.5.1
 	github.com/multiformats/go-multiaddr v0.2.1
 	github.com/multiformats/go-multihash v0.0.13
-	golang.org/x/crypto v0.0.0-20200317142112-1b76d66859c6 // indirect
+	golang.org/x/crypto v0.0.0-20200317142112-1b76d66859c6
 	golang.org/x/sys v0.0.0-20200317113312-5766fd39f98d // indirect
 )
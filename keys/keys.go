@@ -0,0 +1,135 @@
+// Package keys provides PRE/OT key pairs, public key fingerprints, PEM and
+// JSON encodings, and a passphrase-encrypted keystore.
+package keys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// SecretKeySize is the size of an encoded secret key.
+const SecretKeySize = 32
+
+var (
+	// ErrInvalidSecretKey occurs when a secret key is zero or not less than curve.Order.
+	ErrInvalidSecretKey = errors.New("invalid secret key")
+
+	// ErrKeyMismatch occurs when a decoded public key does not belong to the secret key.
+	ErrKeyMismatch = errors.New("public key does not match secret key")
+)
+
+// KeyPair is a secret scalar sk with its G1 public key sk * G1.
+type KeyPair struct {
+	SecretKey *big.Int
+	PublicKey curve.Point
+}
+
+// GenerateKeyPair generates a key pair with randomness read from r, or from
+// crypto/rand.Reader if r is nil.
+func GenerateKeyPair(r io.Reader) (*KeyPair, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	sk, pk, err := curve.NewRandomPoint(curve.TypeG1, r)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{SecretKey: sk, PublicKey: pk}, nil
+}
+
+// NewKeyPair returns the key pair of secret key sk.
+func NewKeyPair(sk *big.Int) (*KeyPair, error) {
+	if sk.Sign() <= 0 || sk.Cmp(curve.Order) >= 0 {
+		return nil, ErrInvalidSecretKey
+	}
+	return &KeyPair{
+		SecretKey: new(big.Int).Set(sk),
+		PublicKey: curve.NewPoint(curve.TypeG1).ScalarBaseMult(sk),
+	}, nil
+}
+
+// PublicKeyG2 returns sk * G2, the public key receivers publish for
+// pre.GenerateReKeyFromPublicKey.
+func (kp *KeyPair) PublicKeyG2() curve.Point {
+	return curve.NewPoint(curve.TypeG2).ScalarBaseMult(kp.SecretKey)
+}
+
+// Fingerprint returns the fingerprint of the G1 public key.
+func (kp *KeyPair) Fingerprint() Fingerprint {
+	return NewFingerprint(kp.PublicKey)
+}
+
+// marshalSecretKey encodes the secret key as 32-byte big endian.
+func (kp *KeyPair) marshalSecretKey() []byte {
+	b := kp.SecretKey.Bytes()
+	return append(make([]byte, SecretKeySize-len(b)), b...)
+}
+
+func unmarshalSecretKey(m []byte) (*KeyPair, error) {
+	if len(m) != SecretKeySize {
+		return nil, ErrInvalidSecretKey
+	}
+	return NewKeyPair(new(big.Int).SetBytes(m))
+}
+
+// Fingerprint is the SHA256 of the curve type and encoding of a public key.
+type Fingerprint [sha256.Size]byte
+
+// NewFingerprint returns the fingerprint of public key pk of any curve type.
+func NewFingerprint(pk curve.Point) Fingerprint {
+	h := sha256.New()
+	h.Write([]byte(pk.Curve().String()))
+	h.Write(pk.Marshal())
+	var fp Fingerprint
+	copy(fp[:], h.Sum(nil))
+	return fp
+}
+
+// String returns the hex encoding of the fingerprint.
+func (fp Fingerprint) String() string {
+	return hex.EncodeToString(fp[:])
+}
+
+type keyPairJSON struct {
+	SecretKey   string `json:"secret_key"`
+	PublicKey   string `json:"public_key"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// MarshalJSON encodes the key pair with hex encoded keys.
+func (kp *KeyPair) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&keyPairJSON{
+		SecretKey:   hex.EncodeToString(kp.marshalSecretKey()),
+		PublicKey:   hex.EncodeToString(kp.PublicKey.Marshal()),
+		Fingerprint: kp.Fingerprint().String(),
+	})
+}
+
+// UnmarshalJSON decodes a key pair encoded by MarshalJSON, and checks that
+// the public key belongs to the secret key.
+func (kp *KeyPair) UnmarshalJSON(data []byte) error {
+	var v keyPairJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	sk, err := hex.DecodeString(v.SecretKey)
+	if err != nil {
+		return err
+	}
+	decoded, err := unmarshalSecretKey(sk)
+	if err != nil {
+		return err
+	}
+	if v.PublicKey != hex.EncodeToString(decoded.PublicKey.Marshal()) {
+		return ErrKeyMismatch
+	}
+	*kp = *decoded
+	return nil
+}
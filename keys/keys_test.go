@@ -0,0 +1,156 @@
+package keys_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/clarenous/proxyot/keys"
+	"github.com/clarenous/proxyot/pre"
+)
+
+func TestEncoding(t *testing.T) {
+	kp, err := keys.GenerateKeyPair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// pem
+	decoded, err := keys.ParseKeyPairPEM(kp.MarshalPEM())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.SecretKey.Cmp(kp.SecretKey) != 0 || decoded.Fingerprint() != kp.Fingerprint() {
+		t.Errorf("pem key pair mismatch")
+	}
+	pkG1, err := keys.ParsePublicKeyPEM(keys.MarshalPublicKeyPEM(kp.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.NewFingerprint(pkG1) != kp.Fingerprint() {
+		t.Errorf("pem G1 public key mismatch")
+	}
+	pkG2, err := keys.ParsePublicKeyPEM(keys.MarshalPublicKeyPEM(kp.PublicKeyG2()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pkG2.Marshal(), pre.ReceiverPublicKey(kp.SecretKey).Marshal()) {
+		t.Errorf("pem G2 public key mismatch")
+	}
+	if keys.NewFingerprint(pkG2) == kp.Fingerprint() {
+		t.Errorf("G1 and G2 fingerprints collide")
+	}
+	if _, err = keys.ParseKeyPairPEM(keys.MarshalPublicKeyPEM(kp.PublicKey)); err == nil {
+		t.Errorf("parsed a public key as a key pair")
+	}
+
+	// json
+	data, err := json.Marshal(kp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded = new(keys.KeyPair)
+	if err = json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.SecretKey.Cmp(kp.SecretKey) != 0 || decoded.Fingerprint() != kp.Fingerprint() {
+		t.Errorf("json key pair mismatch")
+	}
+	other, err := keys.GenerateKeyPair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]string
+	if err = json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	otherData, err := json.Marshal(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var otherFields map[string]string
+	if err = json.Unmarshal(otherData, &otherFields); err != nil {
+		t.Fatal(err)
+	}
+	fields["public_key"] = otherFields["public_key"]
+	if data, err = json.Marshal(fields); err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(data, new(keys.KeyPair)); !errors.Is(err, keys.ErrKeyMismatch) {
+		t.Errorf("want ErrKeyMismatch, got %v", err)
+	}
+}
+
+func TestKeyStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "alice.json")
+
+	kp, err := keys.GenerateKeyPair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passphrase := []byte("correct horse battery staple")
+	if err = keys.SaveKeyStore(path, kp, passphrase, keys.LightScrypt); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := keys.LoadKeyStore(path, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.SecretKey.Cmp(kp.SecretKey) != 0 || loaded.Fingerprint() != kp.Fingerprint() {
+		t.Errorf("loaded key pair mismatch")
+	}
+
+	if _, err = keys.LoadKeyStore(path, []byte("wrong")); !errors.Is(err, keys.ErrWrongPassphrase) {
+		t.Errorf("want ErrWrongPassphrase, got %v", err)
+	}
+
+	// the clear public key is authenticated
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	other, err := keys.GenerateKeyPair(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherData, err := keys.EncryptKeyPair(other, passphrase, keys.LightScrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var otherFields map[string]interface{}
+	if err = json.Unmarshal(otherData, &otherFields); err != nil {
+		t.Fatal(err)
+	}
+	fields["public_key"] = otherFields["public_key"]
+	if data, err = json.Marshal(fields); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = keys.DecryptKeyPair(data, passphrase); !errors.Is(err, keys.ErrWrongPassphrase) {
+		t.Errorf("swapped public key: want ErrWrongPassphrase, got %v", err)
+	}
+
+	// unbounded scrypt costs are rejected before deriving the key
+	for _, params := range []keys.ScryptParams{{N: 1 << 30, R: 8, P: 1}, {N: 1 << 15, R: 8, P: 1 << 20}, {N: 1 << 15, R: 0, P: 1}} {
+		otherFields["kdf_params"] = params
+		if data, err = json.Marshal(otherFields); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = keys.DecryptKeyPair(data, passphrase); err == nil || errors.Is(err, keys.ErrWrongPassphrase) {
+			t.Errorf("scrypt params %+v: want bounds error, got %v", params, err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package keys
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// A keystore file holds a secret key encrypted by AES-256-GCM, under a key
+// derived from the passphrase by scrypt. The public key is stored in clear
+// and authenticated as additional data.
+
+const (
+	keystoreVersion = 1
+	keystoreKDF     = "scrypt"
+	keystoreCipher  = "aes-256-gcm"
+
+	scryptKeySize  = 32
+	scryptSaltSize = 32
+
+	// bounds of the scrypt parameters read from keystores: memory is 128*N*R
+	// bytes, and work grows with 128*N*R*P
+	maxScryptMemory = 256 << 20
+	maxScryptWork   = 1 << 30
+)
+
+var (
+	// ErrWrongPassphrase occurs when a keystore can not be decrypted with the passphrase.
+	ErrWrongPassphrase = errors.New("wrong passphrase or corrupt keystore")
+
+	errInvalidKeystore = errors.New("invalid keystore")
+	errScryptParams    = errors.New("scrypt parameters out of bounds")
+)
+
+// ScryptParams are the cost parameters of scrypt.
+type ScryptParams struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+var (
+	// StandardScrypt takes about 100ms and 32MB of memory on a modern CPU.
+	StandardScrypt = ScryptParams{N: 1 << 15, R: 8, P: 1}
+
+	// LightScrypt takes about 10ms and 4MB of memory, for constrained devices and tests.
+	LightScrypt = ScryptParams{N: 1 << 12, R: 8, P: 1}
+)
+
+type keystoreJSON struct {
+	Version     int          `json:"version"`
+	PublicKey   string       `json:"public_key"`
+	Fingerprint string       `json:"fingerprint"`
+	KDF         string       `json:"kdf"`
+	KDFParams   ScryptParams `json:"kdf_params"`
+	Salt        string       `json:"salt"`
+	Cipher      string       `json:"cipher"`
+	Nonce       string       `json:"nonce"`
+	Ciphertext  string       `json:"ciphertext"`
+}
+
+// EncryptKeyPair encrypts kp with passphrase into a JSON keystore.
+func EncryptKeyPair(kp *KeyPair, passphrase []byte, params ScryptParams) ([]byte, error) {
+	salt := make([]byte, scryptSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	aead, err := newKeystoreAEAD(passphrase, salt, params)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	publicKey := kp.PublicKey.Marshal()
+	return json.MarshalIndent(&keystoreJSON{
+		Version:     keystoreVersion,
+		PublicKey:   hex.EncodeToString(publicKey),
+		Fingerprint: kp.Fingerprint().String(),
+		KDF:         keystoreKDF,
+		KDFParams:   params,
+		Salt:        hex.EncodeToString(salt),
+		Cipher:      keystoreCipher,
+		Nonce:       hex.EncodeToString(nonce),
+		Ciphertext:  hex.EncodeToString(aead.Seal(nil, nonce, kp.marshalSecretKey(), publicKey)),
+	}, "", "  ")
+}
+
+// DecryptKeyPair decrypts a keystore encrypted by EncryptKeyPair.
+func DecryptKeyPair(data, passphrase []byte) (*KeyPair, error) {
+	var ks keystoreJSON
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, err
+	}
+	if ks.Version != keystoreVersion || ks.KDF != keystoreKDF || ks.Cipher != keystoreCipher {
+		return nil, fmt.Errorf("unsupported keystore: version %d, kdf %q, cipher %q", ks.Version, ks.KDF, ks.Cipher)
+	}
+	var salt, nonce, ciphertext, publicKey []byte
+	for _, field := range []struct {
+		dst *[]byte
+		src string
+	}{{&salt, ks.Salt}, {&nonce, ks.Nonce}, {&ciphertext, ks.Ciphertext}, {&publicKey, ks.PublicKey}} {
+		var err error
+		if *field.dst, err = hex.DecodeString(field.src); err != nil {
+			return nil, errInvalidKeystore
+		}
+	}
+	aead, err := newKeystoreAEAD(passphrase, salt, ks.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, errInvalidKeystore
+	}
+	sk, err := aead.Open(nil, nonce, ciphertext, publicKey)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	kp, err := unmarshalSecretKey(sk)
+	if err != nil {
+		return nil, err
+	}
+	if hex.EncodeToString(kp.PublicKey.Marshal()) != ks.PublicKey {
+		return nil, ErrKeyMismatch
+	}
+	return kp, nil
+}
+
+// SaveKeyStore writes kp encrypted with passphrase to the file at path.
+func SaveKeyStore(path string, kp *KeyPair, passphrase []byte, params ScryptParams) error {
+	data, err := EncryptKeyPair(kp, passphrase, params)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// LoadKeyStore reads and decrypts the keystore file at path.
+func LoadKeyStore(path string, passphrase []byte) (*KeyPair, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptKeyPair(data, passphrase)
+}
+
+// check rejects parameters out of bounds, so that a crafted keystore can not
+// make scrypt take unbounded memory and time.
+func (params ScryptParams) check() error {
+	if params.N < 2 || params.R < 1 || params.P < 1 {
+		return errScryptParams
+	}
+	if params.N > maxScryptMemory/128/params.R || params.P > maxScryptWork/(128*params.N*params.R) {
+		return errScryptParams
+	}
+	return nil
+}
+
+func newKeystoreAEAD(passphrase, salt []byte, params ScryptParams) (cipher.AEAD, error) {
+	if err := params.check(); err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key(passphrase, salt, params.N, params.R, params.P, scryptKeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,70 @@
+package keys
+
+import (
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+const (
+	pemSecretKey = "PROXYOT SECRET KEY"
+	pemPublicKey = "PROXYOT PUBLIC KEY"
+
+	pemHeaderCurve = "Curve"
+)
+
+var errInvalidPEM = errors.New("invalid pem block")
+
+// MarshalPEM encodes the secret key as a PEM block.
+func (kp *KeyPair) MarshalPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  pemSecretKey,
+		Bytes: kp.marshalSecretKey(),
+	})
+}
+
+// ParseKeyPairPEM decodes a key pair encoded by MarshalPEM.
+func ParseKeyPairPEM(data []byte) (*KeyPair, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != pemSecretKey {
+		return nil, errInvalidPEM
+	}
+	return unmarshalSecretKey(block.Bytes)
+}
+
+// MarshalPublicKeyPEM encodes a G1 or G2 public key as a PEM block, with the
+// curve type in the "Curve" header.
+func MarshalPublicKeyPEM(pk curve.Point) []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:    pemPublicKey,
+		Headers: map[string]string{pemHeaderCurve: pk.Curve().String()},
+		Bytes:   pk.Marshal(),
+	})
+}
+
+// ParsePublicKeyPEM decodes a public key encoded by MarshalPublicKeyPEM.
+func ParsePublicKeyPEM(data []byte) (curve.Point, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != pemPublicKey {
+		return nil, errInvalidPEM
+	}
+	var pk curve.Point
+	switch block.Headers[pemHeaderCurve] {
+	case curve.TypeG1.String():
+		pk = curve.NewPoint(curve.TypeG1)
+	case curve.TypeG2.String():
+		pk = curve.NewPoint(curve.TypeG2)
+	default:
+		return nil, fmt.Errorf("unsupported public key curve: %q", block.Headers[pemHeaderCurve])
+	}
+	rest, err := pk.Unmarshal(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errInvalidPEM
+	}
+	return pk, nil
+}
@@ -65,3 +65,53 @@ func computePair(left, right *Hash) *Hash {
 	copy(data[sha256.Size:], right.Bytes())
 	return SHA256(data).Ptr()
 }
+
+// Proof is the path from leaf Index of a tree of Count leaves to its root.
+// Siblings holds the sibling of every layer, except the layers in which the
+// node is the last of an odd count and is hashed alone.
+type Proof struct {
+	Index    int
+	Count    int
+	Siblings []Hash
+}
+
+// Proof returns the proof of leaf index, or nil if index is out of range.
+func (tree *Tree) Proof(index int) *Proof {
+	if index < 0 || index >= len(tree.Leaves) {
+		return nil
+	}
+	proof := &Proof{Index: index, Count: len(tree.Leaves)}
+	layer := make([]*Hash, len(tree.Leaves))
+	copy(layer, tree.Leaves)
+	for i := index; len(layer) > 1; i /= 2 {
+		if sibling := i ^ 1; sibling < len(layer) {
+			proof.Siblings = append(proof.Siblings, *layer[sibling])
+		}
+		layer = computeLayer(layer)
+	}
+	return proof
+}
+
+// VerifyProof verifies that leaf is the leaf of proof in the tree of root.
+func VerifyProof(root, leaf *Hash, proof *Proof) bool {
+	if proof == nil || proof.Index < 0 || proof.Index >= proof.Count {
+		return false
+	}
+	node, siblings := leaf, proof.Siblings
+	for i, n := proof.Index, proof.Count; n > 1; i, n = i/2, (n+1)/2 {
+		if i == n-1 && n%2 == 1 {
+			node = SHA256(node.Bytes()).Ptr()
+			continue
+		}
+		if len(siblings) == 0 {
+			return false
+		}
+		sibling := siblings[0].Ptr()
+		if siblings = siblings[1:]; i%2 == 0 {
+			node = computePair(node, sibling)
+		} else {
+			node = computePair(sibling, node)
+		}
+	}
+	return len(siblings) == 0 && SHA256(node.Bytes()) == *root
+}
@@ -17,6 +17,32 @@ func TestNewTree(t *testing.T) {
 	}
 }
 
+func TestProof(t *testing.T) {
+	for _, count := range []int{1, 2, 3, 5, 8, 9, 15, 16} {
+		leaves := generateLeaves(count)
+		tree := merkle.NewTree(leaves)
+		for i := range leaves {
+			proof := tree.Proof(i)
+			if !merkle.VerifyProof(tree.Root, leaves[i], proof) {
+				t.Fatalf("count %d: leaf %d: verify failed", count, i)
+			}
+			other := merkle.SHA256(leaves[i].Bytes())
+			if merkle.VerifyProof(tree.Root, &other, proof) {
+				t.Fatalf("count %d: leaf %d: verified another leaf", count, i)
+			}
+			if count > 1 {
+				proof.Index = (i + 1) % count
+				if merkle.VerifyProof(tree.Root, leaves[i], proof) {
+					t.Fatalf("count %d: leaf %d: verified at another index", count, i)
+				}
+			}
+		}
+		if tree.Proof(count) != nil {
+			t.Errorf("count %d: proof out of range", count)
+		}
+	}
+}
+
 func generateLeaves(n int) (leaves []*merkle.Hash) {
 	if n <= 0 {
 		return
@@ -65,6 +65,8 @@ const (
 	ctxLPrime       = "l_prime_point"
 	ctxFiles        = "files"
 	ctxFilesCount   = "files_count"
+	ctxSession      = "ot_session"
+	ctxStream       = "stream_re_keys"
 )
 
 var (
@@ -146,6 +148,7 @@ type Alice struct {
 	*protocol.StorageClient
 	PrivateKey *big.Int
 	PublicKey  curve.Point
+	Sessions   *ot.SessionRegistry
 }
 
 func NewAlice(node *node.BaseNode, ctx *ContextWithValue) *Alice {
@@ -154,6 +157,7 @@ func NewAlice(node *node.BaseNode, ctx *ContextWithValue) *Alice {
 		BaseNode:      node,
 		PreClient:     protocol.NewPreClient(node),
 		StorageClient: protocol.NewStorageClient(node),
+		Sessions:      ot.NewSessionRegistry(0),
 	}
 	alice.OtServer = protocol.NewOtServer(alice)
 	var err error
@@ -167,13 +171,35 @@ func NewAlice(node *node.BaseNode, ctx *ContextWithValue) *Alice {
 func (alice *Alice) OnChoiceRequest(choice *protocol.OtChoice) protocol.Error {
 	start := time.Now()
 
-	kps, LPrime, err := ot.CalculateKeyPoints(choice.Y, choice.L, alice.PublicKey, alice.Ctx.Value(ctxFilesCount).(int64))
+	count := alice.Ctx.Value(ctxFilesCount).(int64)
+	session := choice.Session
+	if session == nil || choice.Requester == nil {
+		return protocol.UnknownError("missing session or requester")
+	}
+	if err := session.Check(alice.PublicKey, choice.Requester, choice.Cid.Bytes()); err != nil {
+		return protocol.UnknownError(err.Error())
+	}
+	if err := ot.VerifyChoiceInSession(session, choice.Y, choice.L, count, choice.Proof); err != nil {
+		return protocol.UnknownError(err.Error())
+	}
+	if err := alice.Sessions.Register(session); err != nil {
+		return protocol.UnknownError(err.Error())
+	}
+
+	if stream, _ := alice.Ctx.Value(ctxStream).(bool); stream {
+		return alice.streamReEncrypt(choice, count)
+	}
+
+	kps, LPrime, err := ot.CalculateKeyPoints(choice.Y, choice.L, alice.PublicKey, count)
 	if err != nil {
 		return protocol.UnknownError(err.Error())
 	}
 
 	// generate bob temp keys
-	keys := deriveKeysFromPoints(kps)
+	keys := make([]*big.Int, len(kps))
+	for i := range kps {
+		keys[i] = session.DeriveKey(kps[i])
+	}
 
 	tcAliceCalculateBs.Add(time.Since(start))
 
@@ -191,7 +217,7 @@ func (alice *Alice) OnChoiceRequest(choice *protocol.OtChoice) protocol.Error {
 		Cid:    choice.Cid,
 		LPrime: LPrime,
 		ReKeys: reKeys,
-		TxID:   choice.Cid,
+		TxID:   protocol.SessionTxID(session),
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	time.AfterFunc(replyTimeout, cancel)
@@ -202,6 +228,44 @@ func (alice *Alice) OnChoiceRequest(choice *protocol.OtChoice) protocol.Error {
 	return protocol.NilError()
 }
 
+// streamReEncrypt streams key points, keys and re-keys to the proxy, holding
+// none of them for the whole file set.
+func (alice *Alice) streamReEncrypt(choice *protocol.OtChoice, count int64) protocol.Error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(replyTimeout, cancel)
+
+	stream, err := ot.StreamKeyPoints(ctx, choice.Y, choice.L, alice.PublicKey, count)
+	if err != nil {
+		return protocol.UnknownError(err.Error())
+	}
+	keys := make(chan *big.Int)
+	go func() {
+		defer close(keys)
+		for kp := range stream.KeyPoints {
+			select {
+			case keys <- choice.Session.DeriveKey(kp):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	preArgs := &protocol.PreStreamArgs{
+		Cid:    choice.Cid,
+		LPrime: stream.LPrime,
+		TxID:   protocol.SessionTxID(choice.Session),
+		ReKeys: pre.StreamReKeys(ctx, alice.PrivateKey, keys),
+	}
+	if err := alice.SendReEncryptStream(ctx, alice.Ctx.Value(ctxProxyPeerID).(peer.ID), preArgs); !err.IsNil() {
+		return protocol.UnknownError(fmt.Sprintf("alice stream pre args error: %v", err))
+	}
+	if err := stream.Err(); err != nil {
+		return protocol.UnknownError(fmt.Sprintf("alice stream key points error: %v", err))
+	}
+	return protocol.NilError()
+}
+
 type Bob struct {
 	Ctx *ContextWithValue
 	*node.BaseNode
@@ -247,9 +311,40 @@ func (proxy *Proxy) OnReEncryptRequest(args *protocol.PreArgs) protocol.Error {
 	start := time.Now()
 
 	As := proxy.Ctx.Value(ctxAPoints).([]curve.Point)
-	APrimes := make([]curve.Point, len(As))
-	for i := range args.ReKeys {
-		APrimes[i] = pre.ReEncrypt(As[i], args.ReKeys[i])
+	APrimes, errs, err := pre.ReEncryptBatch(context.Background(), As, args.ReKeys, 0)
+	if err != nil {
+		return protocol.UnknownError(err.Error())
+	}
+	for i := range errs {
+		if errs[i] != nil {
+			return protocol.UnknownError(fmt.Sprintf("re-encrypt capsule %d: %v", i, errs[i]))
+		}
+	}
+
+	tcProxyReEncrypt.Add(time.Since(start))
+
+	proxy.Ctx.SetValue(ctxAPrimePoints, APrimes)
+	proxy.Ctx.SetValue(ctxLPrime, args.LPrime)
+
+	return protocol.NilError()
+}
+
+func (proxy *Proxy) OnReEncryptStream(args *protocol.PreStreamArgs) protocol.Error {
+	start := time.Now()
+
+	As := proxy.Ctx.Value(ctxAPoints).([]curve.Point)
+	APrimes := make([]curve.Point, 0, len(As))
+	for reKey := range args.ReKeys {
+		if len(APrimes) == len(As) {
+			return protocol.UnknownError(pre.ErrLengthMismatch.Error())
+		}
+		APrimes = append(APrimes, pre.ReEncrypt(As[len(APrimes)], reKey))
+	}
+	if err := args.Err(); err != nil {
+		return protocol.UnknownError(err.Error())
+	}
+	if len(APrimes) != len(As) {
+		return protocol.UnknownError(pre.ErrLengthMismatch.Error())
 	}
 
 	tcProxyReEncrypt.Add(time.Since(start))
@@ -285,9 +380,22 @@ func TestNodeP2P(t *testing.T) {
 	}
 }
 
+func TestNodeStreamReEncrypt(t *testing.T) {
+	if err := performNodesInteractionMode(1_000, 600, 300, true); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func performNodesInteraction(FileSize, FileCount, Target int64) error {
+	return performNodesInteractionMode(FileSize, FileCount, Target, false)
+}
+
+// performNodesInteractionMode runs an interaction, with re-keys streamed to
+// the proxy if stream is set.
+func performNodesInteractionMode(FileSize, FileCount, Target int64, stream bool) error {
 	alice, bob, proxy, ctxV, closer := makeThreeParty()
 	defer closer()
+	ctxV.SetValue(ctxStream, stream)
 
 	mh, err := multihash.FromB58String("QmQTw94j68Dgakgtfd45bG3TZG6CAfc427UVRH4mugg4q4")
 	if err != nil {
@@ -325,7 +433,7 @@ func performNodesInteraction(FileSize, FileCount, Target int64) error {
 	downloadTicket := &protocol.DownloadTicket{
 		Owner: curve.NewPoint(curve.TypeG1).ScalarBaseMult(big.NewInt(1)),
 		Cid:   cid.NewCidV0(mh),
-		TxID:  cid.NewCidV0(mh),
+		TxID:  protocol.SessionTxID(bob.Ctx.Value(ctxSession).(*ot.Session)),
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	time.AfterFunc(replyTimeout, cancel)
@@ -368,14 +476,6 @@ func (ctx *ContextWithValue) SetValue(key, value interface{}) {
 	ctx.m.Store(key, value)
 }
 
-func deriveKeysFromPoints(points []curve.Point) []*big.Int {
-	keys := make([]*big.Int, len(points))
-	for i := range points {
-		keys[i] = curve.DeriveFieldElementFromPoint(points[i])
-	}
-	return keys
-}
-
 func makeThreeParty() (*Alice, *Bob, *Proxy, *ContextWithValue, func()) {
 	nodeAlice := node.NewRandomBaseNode(41001)
 	//log.Println("running nodeAlice...", nodeAlice.Host.ID(), nodeAlice.Host.Addrs())
@@ -462,7 +562,7 @@ func decryptFile(bob *Bob, cipher []byte, ordinal int64) (file []byte, err error
 	APrimes := bob.Ctx.Value(ctxAPrimePoints).([]curve.Point)
 	LPrime := bob.Ctx.Value(ctxLPrime).(curve.Point)
 	kp := ot.RevealKeyPoint(LPrime, bob.PrivateKey)
-	b := curve.DeriveFieldElementFromPoint(kp)
+	b := bob.Ctx.Value(ctxSession).(*ot.Session).DeriveKey(kp)
 
 	buf := bytes.NewBuffer(nil)
 	err = pre.DecryptByReceiver(APrimes[ordinal], b, pre.NewDecryptClosure(bytes.NewReader(cipher), buf))
@@ -501,7 +601,12 @@ func uploadFile(alice *Alice, proxy *Proxy, file []byte, mh multihash.Multihash)
 func sendChoice(alice *Alice, bob *Bob, mh multihash.Multihash, target int64) error {
 	start := time.Now()
 
-	yp, lp, err := ot.SealChoice(big.NewInt(target), alice.PublicKey, bob.PublicKey)
+	session, err := ot.NewSession(alice.PublicKey, bob.PublicKey, cid.NewCidV0(mh).Bytes())
+	if err != nil {
+		return err
+	}
+	bob.Ctx.SetValue(ctxSession, session)
+	yp, lp, proof, err := ot.SealChoiceInSession(session, big.NewInt(target), bob.Ctx.Value(ctxFilesCount).(int64))
 	if err != nil {
 		return err
 	}
@@ -509,15 +614,18 @@ func sendChoice(alice *Alice, bob *Bob, mh multihash.Multihash, target int64) er
 	tcBobSendChoice.Add(time.Since(start))
 
 	choice := &protocol.OtChoice{
-		Cid:   cid.NewCidV0(mh),
-		Owner: alice.PublicKey,
-		Y:     yp,
-		L:     lp,
+		Cid:       cid.NewCidV0(mh),
+		Owner:     alice.PublicKey,
+		Y:         yp,
+		L:         lp,
+		Requester: bob.PublicKey,
+		Proof:     proof,
+		Session:   session,
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	time.AfterFunc(replyTimeout, cancel)
 	if err := bob.SendChoice(ctx, alice.Host.ID(), choice); !err.IsNil() {
-		fmt.Errorf("bob send choice: %w", err)
+		return fmt.Errorf("bob send choice: %w", err)
 	}
 	return nil
 }
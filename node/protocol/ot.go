@@ -7,9 +7,11 @@ import (
 
 	"github.com/clarenous/proxyot/curve"
 	msg "github.com/clarenous/proxyot/node/protocol/pb"
+	"github.com/clarenous/proxyot/ot"
 	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multihash"
 )
 
 const (
@@ -18,10 +20,23 @@ const (
 )
 
 type OtChoice struct {
-	Cid   cid.Cid
-	Owner curve.Point
-	Y     curve.Point
-	L     curve.Point
+	Cid       cid.Cid
+	Owner     curve.Point
+	Y         curve.Point
+	L         curve.Point
+	Requester curve.Point     // optional, required to verify Proof
+	Proof     *ot.ChoiceProof // optional, see ot.VerifyChoice
+	Session   *ot.Session     // optional, see ot.VerifyChoiceInSession
+}
+
+// SessionTxID returns the ID of the file proxy ot transaction of session.
+func SessionTxID(session *ot.Session) cid.Cid {
+	hash := session.Hash()
+	mh, err := multihash.Encode(hash[:], multihash.SHA2_256)
+	if err != nil {
+		panic(err) // SHA2_256 of a 32-byte digest never fails
+	}
+	return cid.NewCidV0(mh)
 }
 
 type OtServerNode interface {
@@ -81,7 +96,28 @@ func (p *OtServer) onChoiceRequest(s network.Stream) {
 		Y:     yp,
 		L:     lp,
 	}
-	pErr := p.node.OnChoiceRequest(choice)
+	pErr := NilError()
+	if len(req.Requester) > 0 {
+		choice.Requester = curve.NewPoint(curve.TypeG1)
+		if _, err = choice.Requester.Unmarshal(req.Requester); err != nil {
+			pErr = UnknownError(fmt.Sprintf("bad requester: %v", err))
+		}
+	}
+	if len(req.Proof) > 0 && pErr.IsNil() {
+		choice.Proof = &ot.ChoiceProof{}
+		if err = choice.Proof.Unmarshal(req.Proof); err != nil {
+			pErr = UnknownError(fmt.Sprintf("bad choice proof: %v", err))
+		}
+	}
+	if len(req.Session) > 0 && pErr.IsNil() {
+		choice.Session = &ot.Session{}
+		if err = choice.Session.Unmarshal(req.Session); err != nil {
+			pErr = UnknownError(fmt.Sprintf("bad session: %v", err))
+		}
+	}
+	if pErr.IsNil() {
+		pErr = p.node.OnChoiceRequest(choice)
+	}
 
 	resp := &msg.OtChoiceResponse{
 		ErrorCode: pErr.Code(),
@@ -127,6 +163,15 @@ func (p *OtClient) SendChoice(ctx context.Context, peerID peer.ID, choice *OtCho
 		Yp:    choice.Y.Marshal(),
 		Lp:    choice.L.Marshal(),
 	}
+	if choice.Requester != nil {
+		req.Requester = choice.Requester.Marshal()
+	}
+	if choice.Proof != nil {
+		req.Proof = choice.Proof.Marshal()
+	}
+	if choice.Session != nil {
+		req.Session = choice.Session.Marshal()
+	}
 
 	sendProtoMsg(p.node, peerID, otChoiceRequest, req)
 	v, err := p.pool.Wait(ctx, otChoiceResponse)
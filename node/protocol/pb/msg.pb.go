@@ -26,6 +26,9 @@ type OtChoiceRequest struct {
 	Owner                []byte   `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
 	Yp                   []byte   `protobuf:"bytes,3,opt,name=yp,proto3" json:"yp,omitempty"`
 	Lp                   []byte   `protobuf:"bytes,4,opt,name=lp,proto3" json:"lp,omitempty"`
+	Proof                []byte   `protobuf:"bytes,5,opt,name=proof,proto3" json:"proof,omitempty"`
+	Requester            []byte   `protobuf:"bytes,6,opt,name=requester,proto3" json:"requester,omitempty"`
+	Session              []byte   `protobuf:"bytes,7,opt,name=session,proto3" json:"session,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -92,6 +95,27 @@ func (m *OtChoiceRequest) GetLp() []byte {
 	return nil
 }
 
+func (m *OtChoiceRequest) GetProof() []byte {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *OtChoiceRequest) GetRequester() []byte {
+	if m != nil {
+		return m.Requester
+	}
+	return nil
+}
+
+func (m *OtChoiceRequest) GetSession() []byte {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
 type OtChoiceResponse struct {
 	ErrorCode            uint32   `protobuf:"varint,1,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
 	ErrorMsg             string   `protobuf:"bytes,2,opt,name=error_msg,json=errorMsg,proto3" json:"error_msg,omitempty"`
@@ -557,31 +581,33 @@ func init() {
 func init() { proto.RegisterFile("msg.proto", fileDescriptor_c06e4cca6c2cc899) }
 
 var fileDescriptor_c06e4cca6c2cc899 = []byte{
-	// 387 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x93, 0xcf, 0xce, 0xd2, 0x40,
-	0x14, 0xc5, 0x9d, 0x4e, 0x41, 0x7a, 0x03, 0x5a, 0x07, 0x12, 0x1b, 0x89, 0x4d, 0xd3, 0x55, 0x57,
-	0x6e, 0x7c, 0x03, 0xd1, 0x95, 0xf1, 0xdf, 0x10, 0x17, 0xae, 0x1a, 0x6d, 0x2f, 0x75, 0x62, 0xe9,
-	0x8c, 0x33, 0x25, 0x58, 0xe2, 0x83, 0xf8, 0x48, 0x2e, 0x7d, 0x04, 0x83, 0x2f, 0x62, 0x3a, 0x50,
-	0x28, 0x8b, 0x2f, 0xf9, 0x92, 0x8f, 0xdd, 0x3d, 0x67, 0x71, 0xcf, 0xf9, 0xdd, 0xc9, 0x80, 0xb7,
-	0x36, 0xc5, 0x33, 0xa5, 0x65, 0x2d, 0x19, 0x5d, 0x9b, 0x22, 0xfe, 0x04, 0x0f, 0xdf, 0xd5, 0x8b,
-	0xaf, 0x52, 0x64, 0xc8, 0xf1, 0xfb, 0x06, 0x4d, 0xcd, 0x7c, 0xa0, 0x99, 0xc8, 0x03, 0x12, 0x91,
-	0xc4, 0xe3, 0xed, 0xc8, 0x66, 0x30, 0x90, 0xdb, 0x0a, 0x75, 0xe0, 0x44, 0x24, 0x19, 0xf3, 0x83,
-	0x60, 0x0f, 0xc0, 0x69, 0x54, 0x40, 0xad, 0xe5, 0x34, 0xaa, 0xd5, 0xa5, 0x0a, 0xdc, 0x83, 0x2e,
-	0x55, 0xfc, 0x16, 0xfc, 0xf3, 0x6a, 0xa3, 0x64, 0x65, 0x90, 0x3d, 0x05, 0x40, 0xad, 0xa5, 0x4e,
-	0x33, 0x99, 0xa3, 0x8d, 0x98, 0x70, 0xcf, 0x3a, 0x0b, 0x99, 0x23, 0x9b, 0xc3, 0x41, 0xa4, 0x6b,
-	0x53, 0xd8, 0x30, 0x8f, 0x8f, 0xac, 0xf1, 0xc6, 0x14, 0xf1, 0x0a, 0xa6, 0xef, 0x35, 0x72, 0x7c,
-	0x55, 0x65, 0xba, 0x51, 0xf5, 0xcd, 0x75, 0x7d, 0xa0, 0xa5, 0x52, 0xc7, 0xb2, 0xed, 0xc8, 0x1e,
-	0xc3, 0x7d, 0x8d, 0xe9, 0x37, 0x6c, 0x4c, 0x40, 0x23, 0x9a, 0x8c, 0xf9, 0x50, 0xe3, 0x6b, 0x6c,
-	0x0c, 0x63, 0xe0, 0xd6, 0x3f, 0x44, 0x6e, 0x5b, 0x7b, 0xdc, 0xce, 0x31, 0x87, 0xd9, 0x65, 0xce,
-	0x15, 0xba, 0xff, 0x84, 0x47, 0xcb, 0x5a, 0xea, 0x8f, 0xaa, 0x94, 0x9f, 0xf3, 0xae, 0xf9, 0xe9,
-	0xac, 0xa4, 0x7f, 0x56, 0x1f, 0xa8, 0x11, 0x45, 0xd7, 0xde, 0x88, 0xc2, 0x3a, 0x58, 0xdb, 0x4b,
-	0x4f, 0x78, 0x3b, 0x76, 0xcc, 0xee, 0x99, 0x79, 0x0e, 0xde, 0x4a, 0x94, 0x98, 0x1a, 0xb1, 0xc3,
-	0x60, 0x10, 0x91, 0xc4, 0xe5, 0xa3, 0xd6, 0x58, 0x8a, 0x1d, 0xc6, 0x25, 0xb0, 0x7e, 0xfa, 0xdd,
-	0x79, 0xd8, 0x13, 0x18, 0x6d, 0xec, 0x36, 0xd4, 0xb6, 0x97, 0xc7, 0x4f, 0x3a, 0xfe, 0x00, 0xd3,
-	0x36, 0xed, 0xa5, 0xdc, 0x56, 0xb7, 0xa2, 0x6d, 0x49, 0x9c, 0x33, 0x49, 0xf7, 0x24, 0xb4, 0xf7,
-	0x24, 0x1a, 0x66, 0x97, 0x2b, 0xaf, 0x80, 0x10, 0x02, 0xe4, 0xc7, 0x7d, 0x27, 0x88, 0x9e, 0xf3,
-	0xc2, 0xff, 0xbd, 0x0f, 0xc9, 0x9f, 0x7d, 0x48, 0xfe, 0xee, 0x43, 0xf2, 0xeb, 0x5f, 0x78, 0xef,
-	0xcb, 0xd0, 0xfe, 0x9b, 0xe7, 0xff, 0x03, 0x00, 0x00, 0xff, 0xff, 0x9c, 0x10, 0x4a, 0x46, 0x44,
+	// 419 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x93, 0xdf, 0x6e, 0xd3, 0x30,
+	0x14, 0xc6, 0x71, 0xdd, 0xb5, 0xf5, 0xd1, 0x06, 0xc1, 0xab, 0x84, 0xc5, 0x20, 0xaa, 0x72, 0xd5,
+	0x2b, 0x6e, 0x78, 0x03, 0x06, 0x57, 0x88, 0x7f, 0x9e, 0xb8, 0xae, 0xa0, 0x39, 0x0d, 0x16, 0x69,
+	0x6c, 0xec, 0x4c, 0x23, 0x13, 0x0f, 0xc2, 0x1b, 0xf0, 0x2a, 0x5c, 0xf2, 0x08, 0xa8, 0xbc, 0x08,
+	0xf2, 0xc9, 0xd2, 0x64, 0x17, 0x48, 0x48, 0xdb, 0xdd, 0xf9, 0x3e, 0xcb, 0x3e, 0xdf, 0x2f, 0x9f,
+	0x02, 0x62, 0x1b, 0x8a, 0x27, 0xce, 0xdb, 0xda, 0x4a, 0xbe, 0x0d, 0x45, 0xf6, 0x83, 0xc1, 0xbd,
+	0x37, 0xf5, 0xe9, 0x27, 0x6b, 0xd6, 0xa8, 0xf1, 0xcb, 0x39, 0x86, 0x5a, 0x26, 0xc0, 0xd7, 0x26,
+	0x57, 0x6c, 0xc1, 0x96, 0x42, 0xc7, 0x51, 0xce, 0xe1, 0xc0, 0x5e, 0x54, 0xe8, 0xd5, 0x68, 0xc1,
+	0x96, 0x87, 0xba, 0x15, 0xf2, 0x2e, 0x8c, 0x1a, 0xa7, 0x38, 0x59, 0xa3, 0xc6, 0x45, 0x5d, 0x3a,
+	0x35, 0x6e, 0x75, 0xe9, 0xe2, 0x2d, 0xe7, 0xad, 0xdd, 0xa8, 0x83, 0xf6, 0x16, 0x09, 0xf9, 0x08,
+	0x84, 0x6f, 0x17, 0xa1, 0x57, 0x13, 0x3a, 0xe9, 0x0d, 0xa9, 0x60, 0x1a, 0x30, 0x04, 0x63, 0x2b,
+	0x35, 0xa5, 0xb3, 0x4e, 0x66, 0xaf, 0x21, 0xe9, 0x83, 0x06, 0x67, 0xab, 0x80, 0xf2, 0x31, 0x00,
+	0x7a, 0x6f, 0xfd, 0x6a, 0x6d, 0x73, 0xa4, 0xc0, 0x47, 0x5a, 0x90, 0x73, 0x6a, 0x73, 0x94, 0x27,
+	0xd0, 0x8a, 0xd5, 0x36, 0x14, 0x14, 0x5d, 0xe8, 0x19, 0x19, 0xaf, 0x42, 0x91, 0x6d, 0xe0, 0xf8,
+	0xad, 0x47, 0x8d, 0x2f, 0xaa, 0xb5, 0x6f, 0x5c, 0xfd, 0x6f, 0xf8, 0x04, 0x78, 0xe9, 0xdc, 0x15,
+	0x7a, 0x1c, 0xe5, 0x03, 0x98, 0x7a, 0x5c, 0x7d, 0xc6, 0x26, 0x28, 0xbe, 0xe0, 0xcb, 0x43, 0x3d,
+	0xf1, 0xf8, 0x12, 0x9b, 0x20, 0x25, 0x8c, 0xeb, 0xaf, 0x26, 0xa7, 0x6f, 0x20, 0x34, 0xcd, 0x99,
+	0x86, 0xf9, 0xf5, 0x3d, 0xb7, 0x90, 0xfd, 0x1b, 0xdc, 0x3f, 0xab, 0xad, 0x7f, 0xef, 0x4a, 0xfb,
+	0x21, 0xef, 0x92, 0xef, 0x4b, 0x62, 0xc3, 0x92, 0x12, 0xe0, 0xc1, 0x14, 0x5d, 0xfa, 0x60, 0x0a,
+	0x72, 0xb0, 0xa6, 0xde, 0x8e, 0x74, 0x1c, 0x3b, 0xe6, 0x71, 0xcf, 0x7c, 0x02, 0x62, 0x63, 0x4a,
+	0x5c, 0x05, 0x73, 0x89, 0x54, 0xdf, 0x58, 0xcf, 0xa2, 0x71, 0x66, 0x2e, 0x31, 0x2b, 0x41, 0x0e,
+	0xb7, 0xdf, 0x9c, 0x47, 0x3e, 0x84, 0xd9, 0x39, 0xbd, 0x86, 0x9e, 0x72, 0x09, 0xbd, 0xd7, 0xd9,
+	0x3b, 0x38, 0x8e, 0xdb, 0x9e, 0xdb, 0x8b, 0xea, 0xbf, 0x68, 0x23, 0xc9, 0xa8, 0x27, 0xe9, 0x2a,
+	0xe1, 0x83, 0x4a, 0x3c, 0xcc, 0xaf, 0x3f, 0x79, 0x0b, 0x08, 0x29, 0x40, 0x7e, 0xf5, 0xde, 0x1e,
+	0x62, 0xe0, 0x3c, 0x4b, 0x7e, 0xee, 0x52, 0xf6, 0x6b, 0x97, 0xb2, 0xdf, 0xbb, 0x94, 0x7d, 0xff,
+	0x93, 0xde, 0xf9, 0x38, 0xa1, 0xdf, 0xf0, 0xe9, 0xdf, 0x01, 0x00, 0x7c, 0xf7, 0xd3, 0xfa, 0x93,
 	0x03, 0x00, 0x00,
 }
 
@@ -624,6 +650,24 @@ func (m *OtChoiceRequest) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintMsg(dAtA, i, uint64(len(m.Lp)))
 		i += copy(dAtA[i:], m.Lp)
 	}
+	if len(m.Proof) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintMsg(dAtA, i, uint64(len(m.Proof)))
+		i += copy(dAtA[i:], m.Proof)
+	}
+	if len(m.Requester) > 0 {
+		dAtA[i] = 0x32
+		i++
+		i = encodeVarintMsg(dAtA, i, uint64(len(m.Requester)))
+		i += copy(dAtA[i:], m.Requester)
+	}
+	if len(m.Session) > 0 {
+		dAtA[i] = 0x3a
+		i++
+		i = encodeVarintMsg(dAtA, i, uint64(len(m.Session)))
+		i += copy(dAtA[i:], m.Session)
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
@@ -936,6 +980,18 @@ func (m *OtChoiceRequest) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovMsg(uint64(l))
 	}
+	l = len(m.Proof)
+	if l > 0 {
+		n += 1 + l + sovMsg(uint64(l))
+	}
+	l = len(m.Requester)
+	if l > 0 {
+		n += 1 + l + sovMsg(uint64(l))
+	}
+	l = len(m.Session)
+	if l > 0 {
+		n += 1 + l + sovMsg(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1286,6 +1342,108 @@ func (m *OtChoiceRequest) Unmarshal(dAtA []byte) error {
 				m.Lp = []byte{}
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Proof", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMsg
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMsg
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMsg
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Proof = append(m.Proof[:0], dAtA[iNdEx:postIndex]...)
+			if m.Proof == nil {
+				m.Proof = []byte{}
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Requester", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMsg
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMsg
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMsg
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Requester = append(m.Requester[:0], dAtA[iNdEx:postIndex]...)
+			if m.Requester == nil {
+				m.Requester = []byte{}
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Session", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMsg
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMsg
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMsg
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Session = append(m.Session[:0], dAtA[iNdEx:postIndex]...)
+			if m.Session == nil {
+				m.Session = []byte{}
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMsg(dAtA[iNdEx:])
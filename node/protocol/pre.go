@@ -3,11 +3,14 @@ package protocol
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 
 	"github.com/clarenous/proxyot/curve"
 	msg "github.com/clarenous/proxyot/node/protocol/pb"
+	ggio "github.com/gogo/protobuf/io"
 	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/helpers"
 	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
 )
@@ -15,6 +18,11 @@ import (
 const (
 	preReEncryptRequest  = "/proxyot/pre/reencryptreq/0.0.1"  // alice send re-key to proxy
 	preReEncryptResponse = "/proxyot/pre/reencryptresp/0.0.1" // proxy reply
+
+	preReEncryptStreamRequest = "/proxyot/pre/reencryptstreamreq/0.0.1" // alice stream re-keys to proxy
+
+	preStreamChunkSize  = 256     // re-keys per streamed request
+	preStreamMaxMsgSize = 1 << 20 // bytes per streamed request
 )
 
 type PreArgs struct {
@@ -24,14 +32,42 @@ type PreArgs struct {
 	TxID   cid.Cid
 }
 
+// PreStreamArgs is PreArgs with the re-keys streamed in order, so neither
+// side holds all of them at once.
+type PreStreamArgs struct {
+	Cid    cid.Cid
+	LPrime curve.Point
+	TxID   cid.Cid
+	ReKeys <-chan curve.Point // closed after the last re-key, see Err
+
+	err error
+}
+
+// Err returns the error that closed ReKeys early, once ReKeys is closed.
+func (args *PreStreamArgs) Err() error {
+	return args.err
+}
+
 type PreServerNode interface {
 	BaseNode
 	OnReEncryptRequest(args *PreArgs) Error
 }
 
+// PreStreamServerNode is implemented by server nodes accepting streamed re-keys.
+// OnReEncryptStream should consume args.ReKeys until it is closed.
+type PreStreamServerNode interface {
+	PreServerNode
+	OnReEncryptStream(args *PreStreamArgs) Error
+}
+
 func NewPreServer(node PreServerNode) *PreServer {
 	server := &PreServer{node: node}
 	server.node.SetStreamHandler(preReEncryptRequest, server.onReEncryptRequest)
+	if streamNode, ok := node.(PreStreamServerNode); ok {
+		server.node.SetStreamHandler(preReEncryptStreamRequest, func(s network.Stream) {
+			server.onReEncryptStream(streamNode, s)
+		})
+	}
 	return server
 }
 
@@ -95,6 +131,89 @@ func (p *PreServer) onReEncryptRequest(s network.Stream) {
 	// TODO: response failed ?
 }
 
+// onReEncryptStream reads a stream of delimited PreReEncryptRequest. The first
+// one carries cid, lpp and txid, every one carries a chunk of re-keys.
+func (p *PreServer) onReEncryptStream(node PreStreamServerNode, s network.Stream) {
+	reader := ggio.NewDelimitedReader(s, preStreamMaxMsgSize)
+	req := &msg.PreReEncryptRequest{}
+	if err := reader.ReadMsg(req); err != nil {
+		s.Reset()
+		log.Println(err)
+		return
+	}
+
+	// check msg
+	id, err := cid.Decode(req.Cid)
+	if err != nil {
+		// TODO: wrong cid
+		s.Reset()
+		return
+	}
+
+	lpp := curve.NewPoint(curve.TypeG1)
+	if _, err := lpp.Unmarshal(req.Lpp); err != nil {
+		// TODO: bad lpp
+		s.Reset()
+		return
+	}
+
+	txid, err := cid.Decode(req.Txid)
+	if err != nil {
+		// TODO: wrong txid
+		s.Reset()
+		return
+	}
+
+	reKeys := make(chan curve.Point)
+	done := make(chan struct{})
+	args := &PreStreamArgs{
+		Cid:    id,
+		LPrime: lpp,
+		TxID:   txid,
+		ReKeys: reKeys,
+	}
+	go func() {
+		defer close(reKeys)
+		for {
+			for i := range req.ReKeys {
+				reKey := curve.NewPoint(curve.TypeG2)
+				if _, args.err = reKey.Unmarshal(req.ReKeys[i]); args.err != nil {
+					s.Reset()
+					return
+				}
+				select {
+				case reKeys <- reKey:
+				case <-done:
+					s.Reset()
+					return
+				}
+			}
+			req = &msg.PreReEncryptRequest{}
+			if err := reader.ReadMsg(req); err != nil {
+				if err == io.EOF {
+					s.Close()
+				} else {
+					args.err = err
+					s.Reset()
+				}
+				return
+			}
+		}
+	}()
+	pErr := node.OnReEncryptStream(args)
+	close(done)
+	// drain re-keys the node left unread
+	for range reKeys {
+	}
+
+	resp := &msg.PreReEncryptResponse{
+		ErrorCode: pErr.Code(),
+		ErrorMsg:  pErr.Msg(),
+	}
+	sendProtoMsg(p.node, s.Conn().RemotePeer(), preReEncryptResponse, resp)
+	// TODO: response failed ?
+}
+
 // PreClient type
 type PreClient struct {
 	node BaseNode
@@ -144,3 +263,51 @@ func (p *PreClient) SendReEncrypt(ctx context.Context, peerID peer.ID, args *Pre
 	resp := v.(*msg.PreReEncryptResponse)
 	return NewError(resp.ErrorCode, resp.ErrorMsg)
 }
+
+// SendReEncryptStream sends the re-keys received from args.ReKeys in chunks of
+// delimited PreReEncryptRequest, until args.ReKeys is closed, and waits for
+// the reply of a PreStreamServerNode.
+func (p *PreClient) SendReEncryptStream(ctx context.Context, peerID peer.ID, args *PreStreamArgs) Error {
+	s, err := p.node.NewStream(ctx, peerID, preReEncryptStreamRequest)
+	if err != nil {
+		return UnknownError(fmt.Sprintf("new stream: %v", err))
+	}
+	writer := ggio.NewDelimitedWriter(s)
+	req := &msg.PreReEncryptRequest{
+		Cid:    args.Cid.String(),
+		Lpp:    args.LPrime.Marshal(),
+		ReKeys: make([][]byte, 0, preStreamChunkSize),
+		Txid:   args.TxID.String(),
+	}
+	for closed := false; !closed; {
+		select {
+		case reKey, ok := <-args.ReKeys:
+			if ok {
+				req.ReKeys = append(req.ReKeys, reKey.Marshal())
+			}
+			closed = !ok
+		case <-ctx.Done():
+			s.Reset()
+			return UnknownError(ctx.Err().Error())
+		}
+		if len(req.ReKeys) < preStreamChunkSize && !closed {
+			continue
+		}
+		if err = writer.WriteMsg(req); err != nil {
+			s.Reset()
+			return UnknownError(fmt.Sprintf("write msg: %v", err))
+		}
+		req = &msg.PreReEncryptRequest{ReKeys: make([][]byte, 0, preStreamChunkSize)}
+	}
+	// FullClose closes the stream and waits for the other side to close their half.
+	if err = helpers.FullClose(s); err != nil {
+		s.Reset()
+		return UnknownError(fmt.Sprintf("full close: %v", err))
+	}
+	v, err := p.pool.Wait(ctx, preReEncryptResponse)
+	if err != nil {
+		return UnknownError(err.Error())
+	}
+	resp := v.(*msg.PreReEncryptResponse)
+	return NewError(resp.ErrorCode, resp.ErrorMsg)
+}
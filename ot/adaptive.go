@@ -0,0 +1,336 @@
+package ot
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// Adaptive OT follows Camenisch, Neven and shelat, "Simulatable Adaptive
+// Oblivious Transfer". The sender runs a one-time setup over the set:
+//
+//   secrets x, s, y = x * G2, H = s * e(G1, G2)
+//   A_i = (1/(x+i)) * G1, E_i = k_i XOR SHA512(e(A_i, s * G2))
+//
+// where k_i is a random seed of message i, whose key point is k_i hashed to
+// G1. The transcript (y, H, A_i, E_i) is published once. For every query the
+// receiver blinds A_beta as V = v * A_beta, the sender answers
+// W = s * e(V, G2), and the receiver unblinds e(A_beta, s * G2) = (1/v) * W
+// to open E_beta.
+//
+// Both messages carry proofs. The receiver proves knowledge of v and beta
+// with e(V, y) = v * e(G1, G2) - beta * e(V, G2), so that V is a blinded
+// A_beta and an answer opens a single entry. The sender proves W and H share
+// s, so that a wrong answer fails whatever the choice. Any E_i opens to some
+// seed, a corrupt entry just yields a useless key point: the receiver never
+// aborts on the entry it chose, which would tell the sender its choice.
+
+const (
+	domainAdaptiveKey    = "proxyot/ot/adaptive-key"
+	domainAdaptiveQuery  = "proxyot/ot/adaptive-query"
+	domainAdaptiveAnswer = "proxyot/ot/adaptive-answer"
+)
+
+var (
+	// ErrInvalidTranscript occurs when an adaptive OT transcript is malformed
+	// or its A_i are not A_i = (1/(x+i)) * G1.
+	ErrInvalidTranscript = errors.New("invalid adaptive ot transcript")
+
+	// ErrInvalidQuery occurs when a query is not proven to blind some A_i.
+	ErrInvalidQuery = errors.New("invalid adaptive ot query")
+
+	// ErrInvalidAnswer occurs when an answer is not proven to be computed with
+	// the s of the transcript.
+	ErrInvalidAnswer = errors.New("invalid adaptive ot answer")
+)
+
+var (
+	oneG1 = curve.NewPoint(curve.TypeG1).ScalarBaseMult(big.NewInt(1))
+	oneG2 = curve.NewPoint(curve.TypeG2).ScalarBaseMult(big.NewInt(1))
+	oneGT = curve.NewPoint(curve.TypeGT).ScalarBaseMult(big.NewInt(1))
+
+	zeroG1 = curve.NewPoint(curve.TypeG1).ScalarBaseMult(big.NewInt(0))
+)
+
+// AdaptiveTranscript is the public setup of an adaptive OT.
+type AdaptiveTranscript struct {
+	Y     curve.Point   // y = x * G2
+	H     curve.Point   // H = s * e(G1, G2)
+	As    []curve.Point // As[i-1] = (1/(x+i)) * G1
+	Table [][]byte      // Table[i-1] = E_i
+}
+
+// AdaptiveSender holds the secrets of an adaptive OT setup. It is kept by the
+// sender to answer queries, for as long as the transcript is in use.
+type AdaptiveSender struct {
+	X          *big.Int
+	S          *big.Int
+	Transcript *AdaptiveTranscript
+
+	params *Params
+}
+
+// AdaptiveQuery is the blinded choice V = v * A_beta, with a proof of
+// knowledge of v and beta.
+type AdaptiveQuery struct {
+	V            curve.Point
+	Challenge    *big.Int
+	ResponseV    *big.Int // ResponseV = r1 + Challenge * v
+	ResponseBeta *big.Int // ResponseBeta = r2 + Challenge * beta
+}
+
+// AdaptiveAnswer is the answer W = s * e(V, G2) to a query, with a proof that
+// log_{e(V, G2)} W = log_{e(G1, G2)} H.
+type AdaptiveAnswer struct {
+	W         curve.Point
+	Challenge *big.Int
+	Response  *big.Int // Response = w + Challenge * s
+}
+
+// SetupAdaptive sets up an adaptive OT over count messages. The key points
+// kps are used by the sender like the key points of CalculateKeyPoints.
+func SetupAdaptive(count int64) (sender *AdaptiveSender, kps []curve.Point, err error) {
+	return defaultParams.SetupAdaptive(count)
+}
+
+// SetupAdaptive sets up an adaptive OT over count messages. The key points
+// kps are used by the sender like the key points of CalculateKeyPoints.
+func (params *Params) SetupAdaptive(count int64) (sender *AdaptiveSender, kps []curve.Point, err error) {
+	sender = &AdaptiveSender{params: params}
+	if sender.S, err = curve.RandomFieldElement(params.random()); err != nil {
+		return nil, nil, err
+	}
+	// x + i must be invertible for every i
+	for sender.X == nil {
+		if sender.X, err = curve.RandomFieldElement(params.random()); err != nil {
+			return nil, nil, err
+		}
+		xi := new(big.Int)
+		for i := int64(1); i <= count; i++ {
+			if xi.Add(sender.X, big.NewInt(i)).Mod(xi, curve.Order).Sign() == 0 {
+				sender.X = nil
+				break
+			}
+		}
+	}
+	h := curve.NewPoint(curve.TypeG2).ScalarBaseMult(sender.S)
+	transcript := &AdaptiveTranscript{
+		Y:     curve.NewPoint(curve.TypeG2).ScalarBaseMult(sender.X),
+		H:     curve.NewPoint(curve.TypeGT).ScalarBaseMult(sender.S),
+		As:    make([]curve.Point, count),
+		Table: make([][]byte, count),
+	}
+	kps = make([]curve.Point, count)
+	seed := make([]byte, sealedEntrySize)
+	for i := int64(1); i <= count; i++ {
+		if _, err = io.ReadFull(params.random(), seed); err != nil {
+			return nil, nil, err
+		}
+		kps[i-1] = adaptiveKeyPoint(seed)
+		inv := new(big.Int).Add(sender.X, big.NewInt(i))
+		inv.ModInverse(inv.Mod(inv, curve.Order), curve.Order)
+		transcript.As[i-1] = newPoint().ScalarBaseMult(inv)
+		B := curve.Pair(transcript.As[i-1].(*curve.G1), h.(*curve.G2))
+		transcript.Table[i-1] = maskKeyPoint(seed, B)
+	}
+	sender.Transcript = transcript
+	return sender, kps, nil
+}
+
+// Answer verifies the query of QueryAdaptive and answers it with
+// W = s * e(V, G2), proven against H.
+func (sender *AdaptiveSender) Answer(query *AdaptiveQuery) (answer *AdaptiveAnswer, err error) {
+	if err = query.verify(sender.Transcript); err != nil {
+		return nil, err
+	}
+	params := sender.params
+	if params == nil {
+		params = defaultParams
+	}
+	P := curve.Pair(query.V.(*curve.G1), oneG2.(*curve.G2))
+	answer = &AdaptiveAnswer{W: curve.NewPoint(curve.TypeGT).ScalarMult(P, sender.S)}
+	// DLEQ: T1 = w * e(V, G2), T2 = w * e(G1, G2)
+	w, err := curve.RandomFieldElement(params.random())
+	if err != nil {
+		return nil, err
+	}
+	T1 := curve.NewPoint(curve.TypeGT).ScalarMult(P, w)
+	T2 := curve.NewPoint(curve.TypeGT).ScalarBaseMult(w)
+	answer.Challenge = adaptiveAnswerChallenge(sender.Transcript, query.V, answer.W, T1, T2)
+	answer.Response = new(big.Int).Mul(answer.Challenge, sender.S)
+	answer.Response.Add(answer.Response, w).Mod(answer.Response, curve.Order)
+	return answer, nil
+}
+
+// QueryAdaptive blinds the choice beta as V = v * A_beta. The receiver keeps
+// the blinding factor v to reveal the answer.
+func QueryAdaptive(transcript *AdaptiveTranscript, beta int64) (query *AdaptiveQuery, v *big.Int, err error) {
+	return defaultParams.QueryAdaptive(transcript, beta)
+}
+
+// QueryAdaptive blinds the choice beta as V = v * A_beta. The receiver keeps
+// the blinding factor v to reveal the answer.
+func (params *Params) QueryAdaptive(transcript *AdaptiveTranscript, beta int64) (query *AdaptiveQuery, v *big.Int, err error) {
+	if beta < 1 || beta > int64(len(transcript.As)) {
+		return nil, nil, ErrChoiceOutOfRange
+	}
+	if !isG2(transcript.Y) {
+		return nil, nil, ErrInvalidTranscript
+	}
+	var r1, r2 *big.Int
+	for _, k := range []**big.Int{&v, &r1, &r2} {
+		if *k, err = curve.RandomFieldElement(params.random()); err != nil {
+			return nil, nil, err
+		}
+	}
+	query = &AdaptiveQuery{V: newPoint().ScalarMult(transcript.As[beta-1], v)}
+	// T = r1 * e(G1, G2) - r2 * e(V, G2)
+	P := curve.Pair(query.V.(*curve.G1), oneG2.(*curve.G2))
+	T := curve.NewPoint(curve.TypeGT).ScalarBaseMult(r1)
+	T.Add(T, curve.NewPoint(curve.TypeGT).Neg(curve.NewPoint(curve.TypeGT).ScalarMult(P, r2)))
+	query.Challenge = adaptiveQueryChallenge(transcript, query.V, T)
+	query.ResponseV = new(big.Int).Mul(query.Challenge, v)
+	query.ResponseV.Add(query.ResponseV, r1).Mod(query.ResponseV, curve.Order)
+	query.ResponseBeta = new(big.Int).Mul(query.Challenge, big.NewInt(beta))
+	query.ResponseBeta.Add(query.ResponseBeta, r2).Mod(query.ResponseBeta, curve.Order)
+	return query, v, nil
+}
+
+// RevealAdaptive verifies the answer to the query blinded by v, and opens the
+// key point of beta with it.
+func RevealAdaptive(transcript *AdaptiveTranscript, beta int64, v *big.Int, query *AdaptiveQuery, answer *AdaptiveAnswer) (kp curve.Point, err error) {
+	if beta < 1 || beta > int64(len(transcript.Table)) {
+		return nil, ErrChoiceOutOfRange
+	}
+	if len(transcript.Table[beta-1]) != sealedEntrySize {
+		return nil, ErrInvalidTranscript
+	}
+	if err = answer.verify(transcript, query.V); err != nil {
+		return nil, err
+	}
+	iv := new(big.Int).ModInverse(v, curve.Order)
+	B := curve.NewPoint(curve.TypeGT).ScalarMult(answer.W, iv)
+	return adaptiveKeyPoint(maskKeyPoint(transcript.Table[beta-1], B)), nil
+}
+
+// Verify verifies that every A_i = (1/(x+i)) * G1, i.e. e(A_i, y + i * G2) = e(G1, G2).
+func (transcript *AdaptiveTranscript) Verify() error {
+	if !isG2(transcript.Y) || !isGT(transcript.H) || len(transcript.As) != len(transcript.Table) {
+		return ErrInvalidTranscript
+	}
+	gT := oneGT.Marshal()
+	for i := range transcript.As {
+		A, ok := transcript.As[i].(*curve.G1)
+		if !ok || A == nil || len(transcript.Table[i]) != sealedEntrySize {
+			return ErrInvalidTranscript
+		}
+		yi := curve.NewPoint(curve.TypeG2).ScalarBaseMult(big.NewInt(int64(i + 1)))
+		yi.Add(yi, transcript.Y)
+		if string(curve.Pair(A, yi.(*curve.G2)).Marshal()) != string(gT) {
+			return ErrInvalidTranscript
+		}
+	}
+	return nil
+}
+
+// verify verifies the proof of knowledge of the query:
+// T = ResponseV * e(G1, G2) - ResponseBeta * e(V, G2) - Challenge * e(V, y)
+func (query *AdaptiveQuery) verify(transcript *AdaptiveTranscript) error {
+	if query == nil || query.Challenge == nil || query.ResponseV == nil || query.ResponseBeta == nil {
+		return ErrInvalidQuery
+	}
+	V, ok := query.V.(*curve.G1)
+	if !ok || V == nil || V.String() == zeroG1.String() || !isG2(transcript.Y) {
+		return ErrInvalidQuery
+	}
+	P := curve.Pair(V, oneG2.(*curve.G2))
+	Q := curve.Pair(V, transcript.Y.(*curve.G2))
+	T := curve.NewPoint(curve.TypeGT).ScalarBaseMult(query.ResponseV)
+	T.Add(T, curve.NewPoint(curve.TypeGT).Neg(curve.NewPoint(curve.TypeGT).ScalarMult(P, query.ResponseBeta)))
+	T.Add(T, curve.NewPoint(curve.TypeGT).Neg(curve.NewPoint(curve.TypeGT).ScalarMult(Q, query.Challenge)))
+	if adaptiveQueryChallenge(transcript, V, T).Cmp(query.Challenge) != 0 {
+		return ErrInvalidQuery
+	}
+	return nil
+}
+
+// verify verifies the DLEQ proof of the answer to V:
+// T1 = Response * e(V, G2) - Challenge * W, T2 = Response * e(G1, G2) - Challenge * H
+func (answer *AdaptiveAnswer) verify(transcript *AdaptiveTranscript, V curve.Point) error {
+	if answer == nil || answer.Challenge == nil || answer.Response == nil || !isGT(answer.W) {
+		return ErrInvalidAnswer
+	}
+	G, ok := V.(*curve.G1)
+	if !ok || G == nil || !isGT(transcript.H) {
+		return ErrInvalidAnswer
+	}
+	P := curve.Pair(G, oneG2.(*curve.G2))
+	T1 := curve.NewPoint(curve.TypeGT).ScalarMult(P, answer.Response)
+	T1.Add(T1, curve.NewPoint(curve.TypeGT).Neg(curve.NewPoint(curve.TypeGT).ScalarMult(answer.W, answer.Challenge)))
+	T2 := curve.NewPoint(curve.TypeGT).ScalarBaseMult(answer.Response)
+	T2.Add(T2, curve.NewPoint(curve.TypeGT).Neg(curve.NewPoint(curve.TypeGT).ScalarMult(transcript.H, answer.Challenge)))
+	if adaptiveAnswerChallenge(transcript, V, answer.W, T1, T2).Cmp(answer.Challenge) != 0 {
+		return ErrInvalidAnswer
+	}
+	return nil
+}
+
+func adaptiveQueryChallenge(transcript *AdaptiveTranscript, V, T curve.Point) *big.Int {
+	return curve.DeriveChallenge(domainAdaptiveQuery, transcript.Y.Marshal(), V.Marshal(), T.Marshal())
+}
+
+func adaptiveAnswerChallenge(transcript *AdaptiveTranscript, V, W, T1, T2 curve.Point) *big.Int {
+	return curve.DeriveChallenge(domainAdaptiveAnswer,
+		transcript.H.Marshal(), V.Marshal(), W.Marshal(), T1.Marshal(), T2.Marshal())
+}
+
+// adaptiveKeyPoint hashes the seed of an entry to its key point.
+func adaptiveKeyPoint(seed []byte) curve.Point {
+	return curve.HashToG1(seed, []byte(domainAdaptiveKey))
+}
+
+// Marshal encodes the transcript as:
+// y(128) || H(384) || count(4) || [A(64) || E(64)] * count
+func (transcript *AdaptiveTranscript) Marshal() []byte {
+	buf := append(transcript.Y.Marshal(), transcript.H.Marshal()...)
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(transcript.As)))
+	buf = append(buf, n[:]...)
+	for i := range transcript.As {
+		buf = append(buf, transcript.As[i].Marshal()...)
+		buf = append(buf, transcript.Table[i]...)
+	}
+	return buf
+}
+
+// Unmarshal decodes a transcript encoded by Marshal.
+func (transcript *AdaptiveTranscript) Unmarshal(m []byte) (err error) {
+	Y, H := curve.NewPoint(curve.TypeG2), curve.NewPoint(curve.TypeGT)
+	if m, err = Y.Unmarshal(m); err != nil {
+		return err
+	}
+	if m, err = H.Unmarshal(m); err != nil {
+		return err
+	}
+	if len(m) < 4 {
+		return ErrInvalidTranscript
+	}
+	count := uint64(binary.BigEndian.Uint32(m))
+	if m = m[4:]; uint64(len(m)) != count*2*sealedEntrySize {
+		return ErrInvalidTranscript
+	}
+	As, table := make([]curve.Point, count), make([][]byte, count)
+	for i := range As {
+		As[i] = newPoint()
+		if m, err = As[i].Unmarshal(m); err != nil {
+			return err
+		}
+		table[i] = append([]byte(nil), m[:sealedEntrySize]...)
+		m = m[sealedEntrySize:]
+	}
+	transcript.Y, transcript.H, transcript.As, transcript.Table = Y, H, As, table
+	return nil
+}
@@ -0,0 +1,114 @@
+package ot_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/ot"
+)
+
+func TestAdaptive(t *testing.T) {
+	var count int64 = 10
+
+	sender, kps, err := ot.SetupAdaptive(count)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the receiver keeps the published transcript across queries
+	transcript := &ot.AdaptiveTranscript{}
+	if err = transcript.Unmarshal(sender.Transcript.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	if err = transcript.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, beta := range []int64{3, 9, 1, 3} {
+		query, v, err := ot.QueryAdaptive(transcript, beta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		answer, err := sender.Answer(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kp, err := ot.RevealAdaptive(transcript, beta, v, query, answer)
+		if err != nil {
+			t.Fatalf("beta %d: %v", beta, err)
+		}
+		if kp.String() != kps[beta-1].String() {
+			t.Errorf("beta %d: key point not equal", beta)
+		}
+		// the answer opens no other entry
+		other, err := ot.RevealAdaptive(transcript, beta%count+1, v, query, answer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other.String() == kps[beta%count].String() {
+			t.Errorf("beta %d: answer opened another entry", beta)
+		}
+	}
+
+	// a blinded point that is no A_i is not answered
+	query, _, err := ot.QueryAdaptive(transcript, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forgedQuery := *query
+	forgedQuery.V = curve.NewPoint(curve.TypeG1).ScalarBaseMult(big.NewInt(5))
+	if _, err = sender.Answer(&forgedQuery); !errors.Is(err, ot.ErrInvalidQuery) {
+		t.Errorf("forged query: want ErrInvalidQuery, got %v", err)
+	}
+
+	// a wrong answer is detected whatever the choice
+	for _, beta := range []int64{2, 7} {
+		query, v, err := ot.QueryAdaptive(transcript, beta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		answer, err := sender.Answer(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		answer.W = curve.NewPoint(curve.TypeGT).Add(answer.W, answer.W)
+		if _, err = ot.RevealAdaptive(transcript, beta, v, query, answer); !errors.Is(err, ot.ErrInvalidAnswer) {
+			t.Errorf("beta %d: wrong answer: want ErrInvalidAnswer, got %v", beta, err)
+		}
+	}
+
+	// a corrupt entry opens to a useless key point, without failing, so that
+	// the sender does not learn whether the receiver chose it
+	corrupt := &ot.AdaptiveTranscript{}
+	if err = corrupt.Unmarshal(sender.Transcript.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	corrupt.Table[5][0] ^= 0xff
+	query, v, err := ot.QueryAdaptive(corrupt, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer, err := sender.Answer(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp, err := ot.RevealAdaptive(corrupt, 6, v, query, answer)
+	if err != nil {
+		t.Fatalf("corrupt entry: %v", err)
+	}
+	if kp.String() == kps[5].String() {
+		t.Error("corrupt entry opened the key point")
+	}
+
+	// a transcript with a forged A_i is rejected
+	forged := &ot.AdaptiveTranscript{}
+	if err = forged.Unmarshal(sender.Transcript.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	forged.As[4] = curve.NewPoint(curve.TypeG1).ScalarBaseMult(big.NewInt(5))
+	if err = forged.Verify(); !errors.Is(err, ot.ErrInvalidTranscript) {
+		t.Errorf("forged transcript: want ErrInvalidTranscript, got %v", err)
+	}
+}
@@ -0,0 +1,379 @@
+// Package ext extends a few base OTs of package ot into many 1-out-of-2 OTs
+// of symmetric-key cost, following Ishai, Kilian, Nissim and Petrank,
+// "Extending Oblivious Transfers Efficiently" (IKNP).
+//
+// The roles of the base OTs are reversed: the receiver R of the extended OTs
+// sends Kappa pairs of seeds (k_i^0, k_i^1), and the sender S chooses k_i^s_i
+// by the bits of a random secret s. Then, for m choice bits r:
+//
+//	R: t_i = G(k_i^0), u_i = t_i XOR G(k_i^1) XOR r        (Correlate)
+//	S: q_i = G(k_i^s_i) XOR s_i * u_i = t_i XOR s_i * r   (Correlate)
+//
+// where G is a PRG and t_i, q_i, u_i are columns of m bits. The rows then
+// satisfy q_j = t_j XOR r_j * s, so S masks x_j^0 by H(j, q_j) and x_j^1 by
+// H(j, q_j XOR s), of which R can compute only H(j, t_j) (Transfer, Receive).
+//
+// The extension is secure against a semi-honest receiver; a receiver sending
+// inconsistent u_i may learn bits of s.
+package ext
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/ot"
+)
+
+const (
+	// Kappa is the computational security parameter, the number of base OTs.
+	Kappa = 128
+
+	// SeedSize is the size of a PRG seed sent by a base OT.
+	SeedSize = 16
+
+	rowSize = Kappa / 8
+
+	domainSeed = "proxyot/ot/ext/seed"
+	domainPad  = "proxyot/ot/ext/pad"
+)
+
+var (
+	// ErrBaseNotDone occurs when extending before the base OTs are done.
+	ErrBaseNotDone = errors.New("base ots not done")
+
+	// ErrInvalidMessage occurs when a message of the extension is malformed.
+	ErrInvalidMessage = errors.New("invalid ot extension message")
+
+	// ErrLengthMismatch occurs when a transfer does not match the correlation.
+	ErrLengthMismatch = errors.New("transfer and correlation length mismatch")
+)
+
+// Params is the OT extension handler contains some parameters.
+type Params struct {
+	// Rand is the source of randomness, crypto/rand.Reader is used if nil.
+	Rand io.Reader
+}
+
+// BaseChoices is sent by the sender to start the base OTs.
+type BaseChoices struct {
+	Ys []curve.Point // sealed choices s_i + 1 of ot.SealChoice
+	Ls []curve.Point
+}
+
+// BaseKeys is sent by the receiver to finish the base OTs.
+type BaseKeys struct {
+	LPrimes []curve.Point
+	Seeds   [][2][]byte // Seeds[i][b] = k_i^b XOR H(kp_{b+1})
+}
+
+// Correlation is sent by the receiver to extend len(choices) OTs.
+type Correlation struct {
+	Count int      // m, the number of extended OTs
+	U     [][]byte // U[i] = u_i, (m+7)/8 bytes
+}
+
+// Transfer is sent by the sender with the masked message pairs.
+type Transfer struct {
+	Y [][2][]byte
+}
+
+// Sender sends pairs of messages by the extended OTs.
+type Sender struct {
+	params *Params
+	sk     *big.Int
+	s      [rowSize]byte
+	prgs   []cipher.Stream // G(k_i^s_i)
+
+	first uint64   // index of the first OT of the correlation
+	next  uint64   // index of the first OT of the next correlation
+	rows  [][]byte // q_j
+}
+
+// Receiver receives one message of every pair by the extended OTs.
+type Receiver struct {
+	params *Params
+	pk     curve.Point
+	seeds  [Kappa][2][]byte
+	prgs   [Kappa][2]cipher.Stream
+
+	first   uint64
+	next    uint64
+	rows    [][]byte // t_j
+	choices []bool
+}
+
+// NewSender creates a sender with G1 secret key sk, and the base choices for
+// the receiver with G1 public key pkR.
+func NewSender(pkR curve.Point, sk *big.Int) (*Sender, *BaseChoices, error) {
+	return defaultParams.NewSender(pkR, sk)
+}
+
+// NewSender creates a sender with G1 secret key sk, and the base choices for
+// the receiver with G1 public key pkR.
+func (params *Params) NewSender(pkR curve.Point, sk *big.Int) (*Sender, *BaseChoices, error) {
+	sender := &Sender{params: params, sk: sk}
+	if _, err := io.ReadFull(params.random(), sender.s[:]); err != nil {
+		return nil, nil, err
+	}
+	pk := curve.NewPoint(curve.TypeG1).ScalarBaseMult(sk)
+	choices := &BaseChoices{
+		Ys: make([]curve.Point, Kappa),
+		Ls: make([]curve.Point, Kappa),
+	}
+	base := params.base()
+	for i := 0; i < Kappa; i++ {
+		beta := big.NewInt(int64(bit(sender.s[:], i)) + 1)
+		var err error
+		if choices.Ys[i], choices.Ls[i], err = base.SealChoice(beta, pkR, pk); err != nil {
+			return nil, nil, err
+		}
+	}
+	return sender, choices, nil
+}
+
+// ReceiveBase finishes the base OTs with the seeds chosen by s.
+func (sender *Sender) ReceiveBase(keys *BaseKeys) error {
+	if len(keys.LPrimes) != Kappa || len(keys.Seeds) != Kappa {
+		return ErrInvalidMessage
+	}
+	base := sender.params.base()
+	prgs := make([]cipher.Stream, Kappa)
+	for i := range prgs {
+		masked := keys.Seeds[i][bit(sender.s[:], i)]
+		if len(masked) != SeedSize {
+			return ErrInvalidMessage
+		}
+		seed := maskSeed(masked, base.RevealKeyPoint(keys.LPrimes[i], sender.sk))
+		prgs[i] = newPRG(seed)
+	}
+	sender.prgs = prgs
+	return nil
+}
+
+// Correlate computes the rows q_j of the OTs the receiver extends by c.
+func (sender *Sender) Correlate(c *Correlation) error {
+	if sender.prgs == nil {
+		return ErrBaseNotDone
+	}
+	size := (c.Count + 7) / 8
+	if c.Count < 0 || len(c.U) != Kappa {
+		return ErrInvalidMessage
+	}
+	// check every column before expanding any, so that a malformed message
+	// does not advance the PRGs out of step with the receiver
+	for i := range c.U {
+		if len(c.U[i]) != size {
+			return ErrInvalidMessage
+		}
+	}
+	cols := make([][]byte, Kappa)
+	for i := range cols {
+		cols[i] = expand(sender.prgs[i], size)
+		if bit(sender.s[:], i) == 1 {
+			xorBytes(cols[i], c.U[i])
+		}
+	}
+	sender.rows = transpose(cols, c.Count)
+	sender.first, sender.next = sender.next, sender.next+uint64(c.Count)
+	return nil
+}
+
+// Transfer masks the message pairs of the correlated OTs, pairs[j][0] and
+// pairs[j][1] being x_j^0 and x_j^1 of any length.
+func (sender *Sender) Transfer(pairs [][2][]byte) (*Transfer, error) {
+	if len(pairs) != len(sender.rows) {
+		return nil, ErrLengthMismatch
+	}
+	transfer := &Transfer{Y: make([][2][]byte, len(pairs))}
+	for j, q := range sender.rows {
+		index := sender.first + uint64(j)
+		qs := append([]byte(nil), q...)
+		xorBytes(qs, sender.s[:])
+		transfer.Y[j][0] = pad(index, q, pairs[j][0])
+		transfer.Y[j][1] = pad(index, qs, pairs[j][1])
+	}
+	sender.rows = nil
+	return transfer, nil
+}
+
+// NewReceiver creates a receiver with G1 public key pk, whose discrete
+// logarithm the sender must not know.
+func NewReceiver(pk curve.Point) (*Receiver, error) {
+	return defaultParams.NewReceiver(pk)
+}
+
+// NewReceiver creates a receiver with G1 public key pk, whose discrete
+// logarithm the sender must not know.
+func (params *Params) NewReceiver(pk curve.Point) (*Receiver, error) {
+	receiver := &Receiver{params: params, pk: pk}
+	for i := range receiver.seeds {
+		for b := range receiver.seeds[i] {
+			seed := make([]byte, SeedSize)
+			if _, err := io.ReadFull(params.random(), seed); err != nil {
+				return nil, err
+			}
+			receiver.seeds[i][b] = seed
+			receiver.prgs[i][b] = newPRG(seed)
+		}
+	}
+	return receiver, nil
+}
+
+// RespondBase sends both seeds of every base OT, sealed under the key points
+// of the sender's choices.
+func (receiver *Receiver) RespondBase(choices *BaseChoices) (*BaseKeys, error) {
+	if len(choices.Ys) != Kappa || len(choices.Ls) != Kappa {
+		return nil, ErrInvalidMessage
+	}
+	base := receiver.params.base()
+	keys := &BaseKeys{
+		LPrimes: make([]curve.Point, Kappa),
+		Seeds:   make([][2][]byte, Kappa),
+	}
+	for i := 0; i < Kappa; i++ {
+		kps, LPrime, err := base.CalculateKeyPoints(choices.Ys[i], choices.Ls[i], receiver.pk, 2)
+		if err != nil {
+			return nil, err
+		}
+		keys.LPrimes[i] = LPrime
+		for b := range kps {
+			keys.Seeds[i][b] = maskSeed(receiver.seeds[i][b], kps[b])
+		}
+	}
+	return keys, nil
+}
+
+// Correlate extends len(choices) OTs, choosing x_j^1 if choices[j] is set.
+func (receiver *Receiver) Correlate(choices []bool) *Correlation {
+	size := (len(choices) + 7) / 8
+	r := make([]byte, size)
+	for j, choice := range choices {
+		if choice {
+			r[j/8] |= 1 << (j % 8)
+		}
+	}
+	c := &Correlation{Count: len(choices), U: make([][]byte, Kappa)}
+	cols := make([][]byte, Kappa)
+	for i := range cols {
+		cols[i] = expand(receiver.prgs[i][0], size)
+		c.U[i] = expand(receiver.prgs[i][1], size)
+		xorBytes(c.U[i], cols[i])
+		xorBytes(c.U[i], r)
+	}
+	receiver.rows = transpose(cols, len(choices))
+	receiver.choices = append([]bool(nil), choices...)
+	receiver.first, receiver.next = receiver.next, receiver.next+uint64(len(choices))
+	return c
+}
+
+// Receive unmasks the chosen message of every pair of the transfer.
+func (receiver *Receiver) Receive(transfer *Transfer) ([][]byte, error) {
+	if len(transfer.Y) != len(receiver.rows) {
+		return nil, ErrLengthMismatch
+	}
+	messages := make([][]byte, len(transfer.Y))
+	for j, t := range receiver.rows {
+		var b int
+		if receiver.choices[j] {
+			b = 1
+		}
+		messages[j] = pad(receiver.first+uint64(j), t, transfer.Y[j][b])
+	}
+	receiver.rows, receiver.choices = nil, nil
+	return messages, nil
+}
+
+func (params *Params) base() *ot.Params {
+	return &ot.Params{Rand: params.Rand}
+}
+
+func (params *Params) random() io.Reader {
+	if params.Rand == nil {
+		return rand.Reader
+	}
+	return params.Rand
+}
+
+// maskSeed XORs seed with H(kp).
+func maskSeed(seed []byte, kp curve.Point) []byte {
+	h := sha256.New()
+	h.Write([]byte(domainSeed))
+	h.Write(kp.Marshal())
+	out := h.Sum(nil)[:SeedSize]
+	xorBytes(out, seed)
+	return out
+}
+
+// pad XORs data with H(index, row), expanded to the length of data.
+func pad(index uint64, row, data []byte) []byte {
+	out := append([]byte(nil), data...)
+	var prefix [12]byte
+	binary.BigEndian.PutUint64(prefix[:], index)
+	for counter := 0; counter*sha256.Size < len(out); counter++ {
+		binary.BigEndian.PutUint32(prefix[8:], uint32(counter))
+		h := sha256.New()
+		h.Write([]byte(domainPad))
+		h.Write(prefix[:])
+		h.Write(row)
+		xorBytes(out[counter*sha256.Size:], h.Sum(nil))
+	}
+	return out
+}
+
+// newPRG creates the AES-128-CTR keystream of seed.
+func newPRG(seed []byte) cipher.Stream {
+	block, err := aes.NewCipher(seed)
+	if err != nil {
+		panic(err) // seeds are always SeedSize bytes
+	}
+	return cipher.NewCTR(block, make([]byte, aes.BlockSize))
+}
+
+func expand(prg cipher.Stream, size int) []byte {
+	out := make([]byte, size)
+	prg.XORKeyStream(out, out)
+	return out
+}
+
+// transpose turns Kappa columns of m bits into m rows of Kappa bits.
+func transpose(cols [][]byte, m int) [][]byte {
+	rows := make([][]byte, m)
+	for j := range rows {
+		rows[j] = make([]byte, rowSize)
+	}
+	for i, col := range cols {
+		for j := 0; j < m; j++ {
+			if bit(col, j) == 1 {
+				rows[j][i/8] |= 1 << (i % 8)
+			}
+		}
+	}
+	return rows
+}
+
+func bit(b []byte, i int) byte {
+	return b[i/8] >> (i % 8) & 1
+}
+
+// xorBytes sets dst[i] ^= src[i] for i < min(len(dst), len(src)).
+func xorBytes(dst, src []byte) {
+	if len(src) < len(dst) {
+		dst = dst[:len(src)]
+	}
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
+
+var defaultParams *Params
+
+func init() {
+	defaultParams = &Params{}
+}
@@ -0,0 +1,155 @@
+package ext_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/ot/ext"
+)
+
+func setup(t *testing.T) (*ext.Sender, *ext.Receiver) {
+	skS, _, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, pkR, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, choices, err := ext.NewSender(pkR, skS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver, err := ext.NewReceiver(pkR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := receiver.RespondBase(choices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sender.ReceiveBase(keys); err != nil {
+		t.Fatal(err)
+	}
+	return sender, receiver
+}
+
+func randomPairs(t *testing.T, m int) [][2][]byte {
+	pairs := make([][2][]byte, m)
+	for j := range pairs {
+		for b := range pairs[j] {
+			pairs[j][b] = make([]byte, 1+mrand.Intn(80))
+			if _, err := rand.Read(pairs[j][b]); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return pairs
+}
+
+func TestExtension(t *testing.T) {
+	sender, receiver := setup(t)
+
+	// two extensions over the same base OTs
+	for _, m := range []int{1000, 13} {
+		choices := make([]bool, m)
+		for j := range choices {
+			choices[j] = mrand.Intn(2) == 1
+		}
+		if err := sender.Correlate(receiver.Correlate(choices)); err != nil {
+			t.Fatal(err)
+		}
+		pairs := randomPairs(t, m)
+		transfer, err := sender.Transfer(pairs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		messages, err := receiver.Receive(transfer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := range messages {
+			chosen, other := 0, 1
+			if choices[j] {
+				chosen, other = 1, 0
+			}
+			if !bytes.Equal(messages[j], pairs[j][chosen]) {
+				t.Fatalf("m %d: ot %d: chosen message mismatch", m, j)
+			}
+			if bytes.Equal(transfer.Y[j][other], pairs[j][other]) {
+				t.Fatalf("m %d: ot %d: other message unmasked", m, j)
+			}
+		}
+	}
+}
+
+func TestExtensionErrors(t *testing.T) {
+	skS, _, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, pkR, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, _, err := ext.NewSender(pkR, skS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver, err := ext.NewReceiver(pkR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sender.Correlate(receiver.Correlate(make([]bool, 8))); !errors.Is(err, ext.ErrBaseNotDone) {
+		t.Errorf("before base ots: want ErrBaseNotDone, got %v", err)
+	}
+
+	sender, receiver = setup(t)
+	c := receiver.Correlate(make([]bool, 8))
+	c.U = c.U[1:]
+	if err = sender.Correlate(c); !errors.Is(err, ext.ErrInvalidMessage) {
+		t.Errorf("short correlation: want ErrInvalidMessage, got %v", err)
+	}
+	if err = sender.Correlate(receiver.Correlate(make([]bool, 8))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = sender.Transfer(randomPairs(t, 7)); !errors.Is(err, ext.ErrLengthMismatch) {
+		t.Errorf("short transfer: want ErrLengthMismatch, got %v", err)
+	}
+
+	// a correlation malformed in its last column leaves the sender in step
+	// with the receiver, so that the intact one still transfers
+	sender, receiver = setup(t)
+	choices := []bool{true, false, true, true, false, false, true, false, true}
+	c = receiver.Correlate(choices)
+	malformed := &ext.Correlation{Count: c.Count, U: append([][]byte(nil), c.U...)}
+	malformed.U[ext.Kappa-1] = malformed.U[ext.Kappa-1][1:]
+	if err = sender.Correlate(malformed); !errors.Is(err, ext.ErrInvalidMessage) {
+		t.Errorf("malformed column: want ErrInvalidMessage, got %v", err)
+	}
+	if err = sender.Correlate(c); err != nil {
+		t.Fatal(err)
+	}
+	pairs := randomPairs(t, len(choices))
+	transfer, err := sender.Transfer(pairs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages, err := receiver.Receive(transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for j := range messages {
+		chosen := 0
+		if choices[j] {
+			chosen = 1
+		}
+		if !bytes.Equal(messages[j], pairs[j][chosen]) {
+			t.Fatalf("ot %d: chosen message mismatch after a malformed correlation", j)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package ot
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// k-out-of-n OT runs k independent 1-out-of-n slots, one per choice of the
+// receiver. The sender draws one random key point K_i per message instead of
+// using the slot key points directly, and seals K_i under the key point of
+// every slot:
+//
+//   E_{j,i} = Marshal(K_i) XOR SHA512(kp_{j,i}), kp_{j,i} = t_j * Y_j - i * t_j * pkA
+//
+// The receiver reveals kp_{j,beta_j} of every slot j, so it opens exactly
+// K_{beta_1} ... K_{beta_k}, while every other E_{j,i} stays masked by a key
+// point it can not compute.
+
+const sealedEntrySize = 64 // size of a marshaled G1 point, and of SHA512
+
+var (
+	// ErrDuplicateChoice occurs when a receiver seals the same index twice.
+	ErrDuplicateChoice = errors.New("duplicate choice")
+
+	// ErrInvalidSealedKeys occurs when sealed keys do not match the choices.
+	ErrInvalidSealedKeys = errors.New("invalid sealed keys")
+)
+
+// SealedChoice is the sealed choice of one slot, the (Y, L) of SealChoice.
+type SealedChoice struct {
+	Y curve.Point
+	L curve.Point
+}
+
+// SealedKeys is sent to the receiver of a k-out-of-n OT.
+type SealedKeys struct {
+	LPrimes []curve.Point // LPrimes[j] = t_j * L_j
+	Table   [][][]byte    // Table[j][i-1] = E_{j,i}
+}
+
+// SealChoices seals the distinct indexes betas, one slot per index.
+func SealChoices(betas []*big.Int, pkA, pkB curve.Point) (choices []*SealedChoice, err error) {
+	return defaultParams.SealChoices(betas, pkA, pkB)
+}
+
+// SealChoices seals the distinct indexes betas, one slot per index.
+func (params *Params) SealChoices(betas []*big.Int, pkA, pkB curve.Point) (choices []*SealedChoice, err error) {
+	for i := range betas {
+		for j := 0; j < i; j++ {
+			if betas[i].Cmp(betas[j]) == 0 {
+				return nil, ErrDuplicateChoice
+			}
+		}
+	}
+	choices = make([]*SealedChoice, len(betas))
+	for j, beta := range betas {
+		choice := &SealedChoice{}
+		if choice.Y, choice.L, err = params.SealChoice(beta, pkA, pkB); err != nil {
+			return nil, err
+		}
+		choices[j] = choice
+	}
+	return choices, nil
+}
+
+// CalculateSealedKeyPoints returns count random key points, and the sealed
+// keys from which the receiver of choices opens exactly len(choices) of them.
+func CalculateSealedKeyPoints(choices []*SealedChoice, pkA curve.Point, count int64) (kps []curve.Point, sealed *SealedKeys, err error) {
+	return defaultParams.CalculateSealedKeyPoints(choices, pkA, count)
+}
+
+// CalculateSealedKeyPoints returns count random key points, and the sealed
+// keys from which the receiver of choices opens exactly len(choices) of them.
+func (params *Params) CalculateSealedKeyPoints(choices []*SealedChoice, pkA curve.Point, count int64) (kps []curve.Point, sealed *SealedKeys, err error) {
+	kps = make([]curve.Point, count)
+	for i := range kps {
+		if _, kps[i], err = curve.NewRandomPoint(curve.TypeG1, params.random()); err != nil {
+			return nil, nil, err
+		}
+	}
+	sealed = &SealedKeys{
+		LPrimes: make([]curve.Point, len(choices)),
+		Table:   make([][][]byte, len(choices)),
+	}
+	for j, choice := range choices {
+		var slot []curve.Point
+		if slot, sealed.LPrimes[j], err = params.CalculateKeyPoints(choice.Y, choice.L, pkA, count); err != nil {
+			return nil, nil, err
+		}
+		sealed.Table[j] = make([][]byte, count)
+		for i := range slot {
+			sealed.Table[j][i] = maskKeyPoint(kps[i].Marshal(), slot[i])
+		}
+	}
+	return kps, sealed, nil
+}
+
+// RevealKeyPoints opens the key points of betas, the indexes the receiver
+// sealed by SealChoices in the same order.
+func RevealKeyPoints(sealed *SealedKeys, betas []*big.Int, skB *big.Int) ([]curve.Point, error) {
+	return defaultParams.RevealKeyPoints(sealed, betas, skB)
+}
+
+// RevealKeyPoints opens the key points of betas, the indexes the receiver
+// sealed by SealChoices in the same order.
+func (params *Params) RevealKeyPoints(sealed *SealedKeys, betas []*big.Int, skB *big.Int) ([]curve.Point, error) {
+	if len(sealed.LPrimes) != len(betas) || len(sealed.Table) != len(betas) {
+		return nil, ErrInvalidSealedKeys
+	}
+	kps := make([]curve.Point, len(betas))
+	for j, beta := range betas {
+		if !beta.IsInt64() || beta.Int64() < 1 || beta.Int64() > int64(len(sealed.Table[j])) {
+			return nil, ErrInvalidSealedKeys
+		}
+		entry := sealed.Table[j][beta.Int64()-1]
+		if len(entry) != sealedEntrySize {
+			return nil, ErrInvalidSealedKeys
+		}
+		kp := newPoint()
+		if _, err := kp.Unmarshal(maskKeyPoint(entry, params.RevealKeyPoint(sealed.LPrimes[j], skB))); err != nil {
+			return nil, err
+		}
+		kps[j] = kp
+	}
+	return kps, nil
+}
+
+// Marshal encodes the sealed keys as:
+// k(4) || n(4) || LPrime(64) * k || E(64) * k * n
+func (sealed *SealedKeys) Marshal() []byte {
+	var n int
+	if len(sealed.Table) > 0 {
+		n = len(sealed.Table[0])
+	}
+	buf := make([]byte, 8, 8+len(sealed.LPrimes)*sealedEntrySize*(1+n))
+	binary.BigEndian.PutUint32(buf, uint32(len(sealed.LPrimes)))
+	binary.BigEndian.PutUint32(buf[4:], uint32(n))
+	for _, LPrime := range sealed.LPrimes {
+		buf = append(buf, LPrime.Marshal()...)
+	}
+	for _, slot := range sealed.Table {
+		for _, entry := range slot {
+			buf = append(buf, entry...)
+		}
+	}
+	return buf
+}
+
+// Unmarshal decodes sealed keys encoded by Marshal.
+func (sealed *SealedKeys) Unmarshal(m []byte) (err error) {
+	if len(m) < 8 {
+		return ErrInvalidSealedKeys
+	}
+	k, n := uint64(binary.BigEndian.Uint32(m)), uint64(binary.BigEndian.Uint32(m[4:]))
+	m = m[8:]
+	// len(m) = k*(1+n)*sealedEntrySize, checked without overflowing
+	entries := uint64(len(m)) / sealedEntrySize
+	if uint64(len(m))%sealedEntrySize != 0 || k > entries {
+		return ErrInvalidSealedKeys
+	}
+	if k == 0 && entries != 0 || k != 0 && (entries%k != 0 || n != entries/k-1) {
+		return ErrInvalidSealedKeys
+	}
+	LPrimes := make([]curve.Point, k)
+	for j := range LPrimes {
+		LPrimes[j] = newPoint()
+		if m, err = LPrimes[j].Unmarshal(m); err != nil {
+			return err
+		}
+	}
+	table := make([][][]byte, k)
+	for j := range table {
+		table[j] = make([][]byte, n)
+		for i := range table[j] {
+			table[j][i] = append([]byte(nil), m[:sealedEntrySize]...)
+			m = m[sealedEntrySize:]
+		}
+	}
+	sealed.LPrimes, sealed.Table = LPrimes, table
+	return nil
+}
+
+func maskKeyPoint(data []byte, kp curve.Point) []byte {
+	mask := sha512.Sum512(kp.Marshal())
+	out := make([]byte, sealedEntrySize)
+	for i := range out {
+		out[i] = data[i] ^ mask[i]
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package ot_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/ot"
+)
+
+func TestKOutOfN(t *testing.T) {
+	var count int64 = 20
+	var betas = []*big.Int{big.NewInt(3), big.NewInt(17), big.NewInt(8)}
+
+	_, pkA, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skB, pkB, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	choices, err := ot.SealChoices(betas, pkA, pkB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kps, sealed, err := ot.CalculateSealedKeyPoints(choices, pkA, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the sealed keys travel to the receiver
+	decoded := &ot.SealedKeys{}
+	if err = decoded.Unmarshal(sealed.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	revealed, err := ot.RevealKeyPoints(decoded, betas, skB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chosen := make(map[int64]bool)
+	for j, beta := range betas {
+		chosen[beta.Int64()] = true
+		if !bytes.Equal(kps[beta.Int64()-1].Marshal(), revealed[j].Marshal()) {
+			t.Errorf("slot %d: key point %d not revealed", j, beta.Int64())
+		}
+	}
+
+	// a (k+1)-th key point can not be opened: every slot key point the
+	// receiver can compute only unmasks its own chosen entry
+	slotKeys := make([][]byte, len(betas))
+	for j := range betas {
+		slotKeys[j] = ot.RevealKeyPoint(decoded.LPrimes[j], skB).Marshal()
+	}
+	for i := int64(1); i <= count; i++ {
+		if chosen[i] {
+			continue
+		}
+		want := kps[i-1].Marshal()
+		for j := range decoded.Table {
+			for _, slotKey := range slotKeys {
+				kp := curve.NewPoint(curve.TypeG1)
+				if _, err := kp.Unmarshal(slotKey); err != nil {
+					t.Fatal(err)
+				}
+				if opened, err := openEntry(decoded.Table[j][i-1], kp); err == nil && bytes.Equal(opened, want) {
+					t.Fatalf("key point %d opened with slot %d", i, j)
+				}
+			}
+		}
+		// asking for it in place of a chosen index yields another point
+		other := append([]*big.Int{big.NewInt(i)}, betas[1:]...)
+		if got, err := ot.RevealKeyPoints(decoded, other, skB); err == nil && bytes.Equal(got[0].Marshal(), want) {
+			t.Fatalf("key point %d revealed without being chosen", i)
+		}
+	}
+
+	// duplicate choices are rejected
+	if _, err = ot.SealChoices([]*big.Int{big.NewInt(1), big.NewInt(1)}, pkA, pkB); !errors.Is(err, ot.ErrDuplicateChoice) {
+		t.Errorf("want ErrDuplicateChoice, got %v", err)
+	}
+}
+
+func TestSealedKeysUnmarshalSize(t *testing.T) {
+	for _, m := range [][]byte{
+		// k = 2^31, n = 2^27-1: k*(1+n)*64 wraps to 0
+		{0x80, 0x00, 0x00, 0x00, 0x07, 0xff, 0xff, 0xff},
+		{0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x01},
+		append([]byte{0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x01}, make([]byte, 3*64)...),
+		append([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}, make([]byte, 64)...),
+	} {
+		if err := new(ot.SealedKeys).Unmarshal(m); !errors.Is(err, ot.ErrInvalidSealedKeys) {
+			t.Errorf("%x: want ErrInvalidSealedKeys, got %v", m[:8], err)
+		}
+	}
+}
+
+// openEntry unmasks a sealed entry the way RevealKeyPoints does.
+func openEntry(entry []byte, kp curve.Point) ([]byte, error) {
+	sealed := &ot.SealedKeys{LPrimes: []curve.Point{kp}, Table: [][][]byte{{entry}}}
+	// with sk = 1, RevealKeyPoint(kp, 1) = kp
+	kps, err := ot.RevealKeyPoints(sealed, []*big.Int{big.NewInt(1)}, big.NewInt(1))
+	if err != nil {
+		return nil, err
+	}
+	return kps[0].Marshal(), nil
+}
@@ -0,0 +1,68 @@
+package ot
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// Label-based OT replaces the ordinal i of a message by a field element
+// hashed from its label, e.g. a CID or a file name, so the key point of a
+// message does not depend on its position in the set.
+
+const domainLabel = "proxyot/ot/label"
+
+// ErrDuplicateLabel occurs when a message set has the same label twice.
+var ErrDuplicateLabel = errors.New("duplicate label")
+
+// LabelToFieldElement hashes label to the non-zero field element used in
+// place of an ordinal.
+func LabelToFieldElement(label []byte) *big.Int {
+	return curve.DeriveChallenge(domainLabel, label)
+}
+
+// SealChoiceForLabel seals the choice of the message labeled label.
+func SealChoiceForLabel(label []byte, pkA, pkB curve.Point) (Y curve.Point, L curve.Point, err error) {
+	return defaultParams.SealChoiceForLabel(label, pkA, pkB)
+}
+
+// SealChoiceForLabel seals the choice of the message labeled label.
+func (params *Params) SealChoiceForLabel(label []byte, pkA, pkB curve.Point) (Y curve.Point, L curve.Point, err error) {
+	return params.SealChoice(LabelToFieldElement(label), pkA, pkB)
+}
+
+// CalculateKeyPointsForLabels calculates the key points of the messages
+// labeled labels, kps[j] being the key point of labels[j].
+func CalculateKeyPointsForLabels(Y, L, pkA curve.Point, labels [][]byte) (kps []curve.Point, LPrime curve.Point, err error) {
+	return defaultParams.CalculateKeyPointsForLabels(Y, L, pkA, labels)
+}
+
+// CalculateKeyPointsForLabels calculates the key points of the messages
+// labeled labels, kps[j] being the key point of labels[j]. The labels denied
+// by params.Policy, which must then be a LabelPolicy, are replaced by random
+// points.
+func (params *Params) CalculateKeyPointsForLabels(Y, L, pkA curve.Point, labels [][]byte) (kps []curve.Point, LPrime curve.Point, err error) {
+	ordinals := make([]*big.Int, len(labels))
+	seen := make(map[string]bool, len(labels))
+	for j, label := range labels {
+		if seen[string(label)] {
+			return nil, nil, ErrDuplicateLabel
+		}
+		seen[string(label)] = true
+		ordinals[j] = LabelToFieldElement(label)
+	}
+	// random number t
+	var t *big.Int
+	if t, err = curve.RandomFieldElement(params.random()); err != nil {
+		return
+	}
+	kps = make([]curve.Point, len(labels))
+	for j := range ordinals {
+		if kps[j], err = params.applyLabelPolicy(DefaultClass, labels[j], params.calculateKeyPoint(t, Y, pkA, ordinals[j])); err != nil {
+			return nil, nil, err
+		}
+	}
+	LPrime = newPoint().ScalarMult(L, t)
+	return
+}
@@ -0,0 +1,68 @@
+package ot_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/ot"
+)
+
+func TestLabels(t *testing.T) {
+	labels := [][]byte{
+		[]byte("QmQTw94j68Dgakgtfd45bG3TZG6CAfc427UVRH4mugg4q4"),
+		[]byte("report.pdf"),
+		[]byte("photos/2020/beach.jpg"),
+		[]byte("notes.txt"),
+	}
+	target := []byte("photos/2020/beach.jpg")
+
+	_, pkA, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skB, pkB, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Y, L, err := ot.SealChoiceForLabel(target, pkA, pkB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the set may be reordered or sparse, the key point follows the label
+	for _, set := range [][][]byte{
+		labels,
+		{labels[3], labels[2], labels[1], labels[0]},
+		{labels[2]},
+		{labels[0], labels[2]},
+	} {
+		kps, LPrime, err := ot.CalculateKeyPointsForLabels(Y, L, pkA, set)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kpB := ot.RevealKeyPoint(LPrime, skB).String()
+		for j := range set {
+			match := kps[j].String() == kpB
+			if want := string(set[j]) == string(target); match != want {
+				t.Errorf("label %q: key point match %v, want %v", set[j], match, want)
+			}
+		}
+	}
+
+	// a label missing from the set yields no key point
+	kps, LPrime, err := ot.CalculateKeyPointsForLabels(Y, L, pkA, [][]byte{labels[0], labels[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for j := range kps {
+		if kps[j].String() == ot.RevealKeyPoint(LPrime, skB).String() {
+			t.Errorf("label %q matches", labels[j])
+		}
+	}
+
+	if _, _, err = ot.CalculateKeyPointsForLabels(Y, L, pkA, [][]byte{labels[0], labels[0]}); !errors.Is(err, ot.ErrDuplicateLabel) {
+		t.Errorf("want ErrDuplicateLabel, got %v", err)
+	}
+}
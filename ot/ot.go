@@ -2,13 +2,28 @@ package ot
 
 import (
 	"crypto/rand"
+	"io"
 	"math/big"
+	"runtime"
+	"sync"
 
 	"github.com/clarenous/proxyot/curve"
 )
 
 // Params is the OT handler contains some parameters.
-type Params struct{}
+type Params struct {
+	// Rand is the source of randomness, crypto/rand.Reader is used if nil.
+	// Supplying a deterministic reader reproduces the same transcript.
+	Rand io.Reader
+
+	// Workers is the number of goroutines calculating key points,
+	// runtime.GOMAXPROCS(0) is used if less than 1.
+	Workers int
+
+	// Policy, if not nil, is consulted per ordinal by the sender, see
+	// CalculateKeyPointsForClass.
+	Policy Policy
+}
 
 // New creates an OT handler.
 func New() *Params {
@@ -26,7 +41,7 @@ func SealChoice(beta *big.Int, pkA, pkB curve.Point) (Y curve.Point, L curve.Poi
 func (params *Params) SealChoice(beta *big.Int, pkA, pkB curve.Point) (Y curve.Point, L curve.Point, err error) {
 	// random number l
 	var l *big.Int
-	l, err = curve.RandomFieldElement(rand.Reader)
+	l, err = curve.RandomFieldElement(params.random())
 	if err != nil {
 		return
 	}
@@ -47,20 +62,7 @@ func CalculateKeyPoints(Y, L, pkA curve.Point, count int64) (kps []curve.Point,
 // CalculateKeyPoints refers to the process that sender creates some sealed key points
 // given by the sender calculated Y point.
 func (params *Params) CalculateKeyPoints(Y, L, pkA curve.Point, count int64) (kps []curve.Point, LPrime curve.Point, err error) {
-	// random number t
-	var t *big.Int
-	t, err = curve.RandomFieldElement(rand.Reader)
-	if err != nil {
-		return
-	}
-	// calculate puzzles
-	kps = make([]curve.Point, count)
-	for i := int64(1); i <= count; i++ {
-		kps[i-1] = params.calculateKeyPoint(t, Y, pkA, big.NewInt(i))
-	}
-	// calculate LPrime = t * L
-	LPrime = newPoint().ScalarMult(L, t)
-	return
+	return params.CalculateKeyPointsForClass(Y, L, pkA, count, DefaultClass)
 }
 
 func (params *Params) calculateKeyPoint(t *big.Int, yp, pkA curve.Point, ordinal *big.Int) (kpi curve.Point) {
@@ -71,6 +73,48 @@ func (params *Params) calculateKeyPoint(t *big.Int, yp, pkA curve.Point, ordinal
 	return
 }
 
+// calculateKeyPoints calculates kp_i = t * yp - i * t * pkA for i in [1, count].
+// t * yp is calculated once and every worker steps a chunk of ordinals by
+// -t * pkA with point additions, the only scalar multiplication of a chunk
+// being its first key point.
+func (params *Params) calculateKeyPoints(t *big.Int, yp, pkA curve.Point, count int64) []curve.Point {
+	kps := make([]curve.Point, count)
+	if count <= 0 {
+		return kps
+	}
+	workers := int64(params.Workers)
+	if workers < 1 {
+		workers = int64(runtime.GOMAXPROCS(0))
+	}
+	if workers > count {
+		workers = count
+	}
+	tY := newPoint().ScalarMult(yp, t)
+	step := newPoint().ScalarMult(pkA, t)
+	step.Neg(step)
+	chunk := (count + workers - 1) / workers
+
+	var wg sync.WaitGroup
+	for start := int64(0); start < count; start += chunk {
+		end := start + chunk
+		if end > count {
+			end = count
+		}
+		wg.Add(1)
+		go func(start, end int64) {
+			defer wg.Done()
+			// kps[start] = tY + (start+1) * step
+			kp := newPoint().ScalarMult(step, big.NewInt(start+1))
+			kps[start] = kp.Add(kp, tY)
+			for i := start + 1; i < end; i++ {
+				kps[i] = newPoint().Add(kps[i-1], step)
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	return kps
+}
+
 // RevealKeyPoint refers to the process that receiver reveals real key point
 // given by the sender calculated sealed key point.
 func RevealKeyPoint(LPrime curve.Point, skB *big.Int) curve.Point {
@@ -83,10 +127,36 @@ func (params *Params) RevealKeyPoint(LPrime curve.Point, skB *big.Int) curve.Poi
 	return newPoint().ScalarMult(LPrime, skB)
 }
 
+func (params *Params) random() io.Reader {
+	if params.Rand == nil {
+		return rand.Reader
+	}
+	return params.Rand
+}
+
 func newPoint() curve.Point {
 	return curve.NewPoint(curve.TypeG1)
 }
 
+func g2Generator() *curve.G2 {
+	return curve.NewPoint(curve.TypeG2).ScalarBaseMult(big.NewInt(1)).(*curve.G2)
+}
+
+func isG1(p curve.Point) bool {
+	g, ok := p.(*curve.G1)
+	return ok && g != nil
+}
+
+func isG2(p curve.Point) bool {
+	g, ok := p.(*curve.G2)
+	return ok && g != nil
+}
+
+func isGT(p curve.Point) bool {
+	g, ok := p.(*curve.GT)
+	return ok && g != nil
+}
+
 var defaultParams *Params
 
 func initDefaultParams() {
@@ -1,6 +1,7 @@
 package ot_test
 
 import (
+	"bytes"
 	"crypto/rand"
 	"math/big"
 	"testing"
@@ -40,3 +41,46 @@ func TestOT(t *testing.T) {
 		t.Errorf("Key Point not equal:\nAlice: %s\nBob: %s", kpA.String(), kpB.String())
 	}
 }
+
+func TestCalculateKeyPointsWorkers(t *testing.T) {
+	var count int64 = 37
+
+	_, pkA, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, pkB, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Y, L, err := ot.SealChoice(big.NewInt(5), pkA, pkB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := randomScalar(t)
+	tt := new(big.Int).SetBytes(secret)
+
+	// kp_i = t * Y - i * t * pkA, one ordinal at a time
+	want := make([]string, count)
+	for i := int64(1); i <= count; i++ {
+		kp := curve.NewPoint(curve.TypeG1).ScalarMult(Y, tt)
+		temp := curve.NewPoint(curve.TypeG1).ScalarMult(pkA, new(big.Int).Mul(big.NewInt(i), tt))
+		want[i-1] = kp.Add(kp, temp.Neg(temp)).String()
+	}
+
+	for _, workers := range []int{0, 1, 2, 3, 8, 64} {
+		params := &ot.Params{Rand: bytes.NewReader(secret), Workers: workers}
+		kps, _, err := params.CalculateKeyPoints(Y, L, pkA, count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(len(kps)) != count {
+			t.Fatalf("workers %d: want %d key points, got %d", workers, count, len(kps))
+		}
+		for i := range kps {
+			if kps[i].String() != want[i] {
+				t.Errorf("workers %d: key point %d mismatch", workers, i+1)
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package ot
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// A policy lets the sender restrict ordinals per requester class, e.g. to
+// sell some files to premium requesters only. The key point of an ordinal
+// denied to the class is replaced by a random point, so the receiver of a
+// denied choice derives a key that opens nothing, while the key points of
+// the other ordinals stay as sealed as before: the receiver learns no more
+// than whether its own choice was denied.
+//
+// The policy of Params applies to every key point path: CalculateKeyPoints,
+// StreamKeyPoints, CalculateKeyPointsWithProof, and CalculateSealedKeyPoints,
+// through the key points of every slot. Labels have no ordinal, so
+// CalculateKeyPointsForLabels consults the policy by label, and fails with
+// ErrLabelPolicy if it is not a LabelPolicy. The adaptive OT is set up once for
+// every requester and is not restricted.
+
+// DefaultClass is the requester class of the key point paths other than
+// CalculateKeyPointsForClass.
+const DefaultClass = ""
+
+// Policy decides if the message of ordinal may be sent to requesters of class.
+type Policy interface {
+	Allow(class string, ordinal int64) bool
+}
+
+// LabelPolicy is a Policy that also decides if the message labeled label may
+// be sent to requesters of class.
+type LabelPolicy interface {
+	Policy
+	AllowLabel(class string, label []byte) bool
+}
+
+// ErrLabelPolicy occurs when the policy of the label-based OT is not a
+// LabelPolicy.
+var ErrLabelPolicy = errors.New("policy does not restrict labels")
+
+// PolicyFunc adapts a function to a Policy.
+type PolicyFunc func(class string, ordinal int64) bool
+
+// Allow calls f(class, ordinal).
+func (f PolicyFunc) Allow(class string, ordinal int64) bool {
+	return f(class, ordinal)
+}
+
+// AccessPolicy allows every ordinal and label to every class, except the
+// ordinals and labels restricted to some classes.
+type AccessPolicy struct {
+	l          sync.RWMutex
+	restricted map[int64]map[string]struct{}
+	labels     map[string]map[string]struct{}
+}
+
+// NewAccessPolicy creates a policy allowing everything.
+func NewAccessPolicy() *AccessPolicy {
+	return &AccessPolicy{
+		restricted: make(map[int64]map[string]struct{}),
+		labels:     make(map[string]map[string]struct{}),
+	}
+}
+
+// Restrict allows ordinal to the given classes only, or denies it to every
+// class if none is given.
+func (policy *AccessPolicy) Restrict(ordinal int64, classes ...string) {
+	allowed := make(map[string]struct{}, len(classes))
+	for _, class := range classes {
+		allowed[class] = struct{}{}
+	}
+	policy.l.Lock()
+	policy.restricted[ordinal] = allowed
+	policy.l.Unlock()
+}
+
+// Unrestrict allows ordinal to every class again.
+func (policy *AccessPolicy) Unrestrict(ordinal int64) {
+	policy.l.Lock()
+	delete(policy.restricted, ordinal)
+	policy.l.Unlock()
+}
+
+// Allow implements Policy.
+func (policy *AccessPolicy) Allow(class string, ordinal int64) bool {
+	policy.l.RLock()
+	defer policy.l.RUnlock()
+	allowed, ok := policy.restricted[ordinal]
+	if !ok {
+		return true
+	}
+	_, ok = allowed[class]
+	return ok
+}
+
+// RestrictLabel allows label to the given classes only, or denies it to every
+// class if none is given.
+func (policy *AccessPolicy) RestrictLabel(label []byte, classes ...string) {
+	allowed := make(map[string]struct{}, len(classes))
+	for _, class := range classes {
+		allowed[class] = struct{}{}
+	}
+	policy.l.Lock()
+	policy.labels[string(label)] = allowed
+	policy.l.Unlock()
+}
+
+// UnrestrictLabel allows label to every class again.
+func (policy *AccessPolicy) UnrestrictLabel(label []byte) {
+	policy.l.Lock()
+	delete(policy.labels, string(label))
+	policy.l.Unlock()
+}
+
+// AllowLabel implements LabelPolicy.
+func (policy *AccessPolicy) AllowLabel(class string, label []byte) bool {
+	policy.l.RLock()
+	defer policy.l.RUnlock()
+	allowed, ok := policy.labels[string(label)]
+	if !ok {
+		return true
+	}
+	_, ok = allowed[class]
+	return ok
+}
+
+// CalculateKeyPointsForClass calculates key points like CalculateKeyPoints for
+// a requester of class, with the key points of the ordinals params.Policy
+// denies to class replaced by random points.
+func (params *Params) CalculateKeyPointsForClass(Y, L, pkA curve.Point, count int64, class string) (kps []curve.Point, LPrime curve.Point, err error) {
+	// random number t
+	var t *big.Int
+	t, err = curve.RandomFieldElement(params.random())
+	if err != nil {
+		return
+	}
+	kps = params.calculateKeyPoints(t, Y, pkA, count)
+	for i := range kps {
+		if kps[i], err = params.applyPolicy(class, int64(i+1), kps[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	// calculate LPrime = t * L
+	LPrime = newPoint().ScalarMult(L, t)
+	return
+}
+
+// applyPolicy returns kp if params.Policy allows ordinal to class, or a random
+// point otherwise.
+func (params *Params) applyPolicy(class string, ordinal int64, kp curve.Point) (curve.Point, error) {
+	if params.Policy == nil || params.Policy.Allow(class, ordinal) {
+		return kp, nil
+	}
+	_, p, err := curve.NewRandomPoint(curve.TypeG1, params.random())
+	return p, err
+}
+
+// applyLabelPolicy returns kp if params.Policy allows label to class, or a
+// random point otherwise.
+func (params *Params) applyLabelPolicy(class string, label []byte, kp curve.Point) (curve.Point, error) {
+	if params.Policy == nil {
+		return kp, nil
+	}
+	policy, ok := params.Policy.(LabelPolicy)
+	if !ok {
+		return nil, ErrLabelPolicy
+	}
+	if policy.AllowLabel(class, label) {
+		return kp, nil
+	}
+	_, p, err := curve.NewRandomPoint(curve.TypeG1, params.random())
+	return p, err
+}
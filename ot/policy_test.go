@@ -0,0 +1,135 @@
+package ot_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/ot"
+)
+
+func TestAccessPolicy(t *testing.T) {
+	var count int64 = 10
+	const (
+		premium    = "premium"
+		restricted = 3 // premium only
+		denied     = 7 // nobody
+	)
+
+	policy := ot.NewAccessPolicy()
+	policy.Restrict(restricted, premium)
+	policy.Restrict(denied)
+	sender := &ot.Params{Policy: policy}
+
+	_, pkA, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skB, pkB, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		class string
+		beta  int64
+		allow bool
+	}{
+		{ot.DefaultClass, 1, true},
+		{ot.DefaultClass, restricted, false},
+		{premium, restricted, true},
+		{ot.DefaultClass, denied, false},
+		{premium, denied, false},
+	} {
+		Y, L, err := ot.SealChoice(big.NewInt(tc.beta), pkA, pkB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kps, LPrime, err := sender.CalculateKeyPointsForClass(Y, L, pkA, count, tc.class)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kp := ot.RevealKeyPoint(LPrime, skB)
+		if got := kps[tc.beta-1].String() == kp.String(); got != tc.allow {
+			t.Errorf("class %q, ordinal %d: want allowed %v, got %v", tc.class, tc.beta, tc.allow, got)
+		}
+	}
+
+	// CalculateKeyPoints consults the policy with DefaultClass
+	Y, L, err := ot.SealChoice(big.NewInt(restricted), pkA, pkB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kps, LPrime, err := sender.CalculateKeyPoints(Y, L, pkA, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kps[restricted-1].String() == ot.RevealKeyPoint(LPrime, skB).String() {
+		t.Errorf("restricted ordinal sent to the default class")
+	}
+
+	// so does every other key point path
+	kps, LPrime, _, _, err = sender.CalculateKeyPointsWithProof(Y, L, pkA, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kps[restricted-1].String() == ot.RevealKeyPoint(LPrime, skB).String() {
+		t.Errorf("restricted ordinal sent with proof")
+	}
+	// labels are restricted by label, wherever they are in the set
+	policy.RestrictLabel([]byte("b"))
+	labelY, labelL, err := ot.SealChoiceForLabel([]byte("b"), pkA, pkB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, labels := range [][][]byte{
+		{[]byte("a"), []byte("b"), []byte("c")},
+		{[]byte("b"), []byte("c"), []byte("a")},
+		{[]byte("c"), []byte("a"), []byte("b")},
+	} {
+		if kps, LPrime, err = sender.CalculateKeyPointsForLabels(labelY, labelL, pkA, labels); err != nil {
+			t.Fatal(err)
+		}
+		kp := ot.RevealKeyPoint(LPrime, skB)
+		for j := range labels {
+			if string(labels[j]) == "b" && kps[j].String() == kp.String() {
+				t.Errorf("labels %q: restricted label sent", labels)
+			}
+		}
+		allowedY, allowedL, err := ot.SealChoiceForLabel(labels[0], pkA, pkB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kps, LPrime, err = sender.CalculateKeyPointsForLabels(allowedY, allowedL, pkA, labels); err != nil {
+			t.Fatal(err)
+		}
+		if allowed := kps[0].String() == ot.RevealKeyPoint(LPrime, skB).String(); allowed == (string(labels[0]) == "b") {
+			t.Errorf("labels %q: label %q allowed %v", labels, labels[0], allowed)
+		}
+	}
+	// a policy of ordinals only does not apply to labels
+	ordinalsOnly := &ot.Params{Policy: ot.PolicyFunc(func(string, int64) bool { return true })}
+	if _, _, err = ordinalsOnly.CalculateKeyPointsForLabels(labelY, labelL, pkA, [][]byte{[]byte("b")}); !errors.Is(err, ot.ErrLabelPolicy) {
+		t.Errorf("want ErrLabelPolicy, got %v", err)
+	}
+	betas := []*big.Int{big.NewInt(1), big.NewInt(restricted)}
+	choices, err := ot.SealChoices(betas, pkA, pkB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kps, sealed, err := sender.CalculateSealedKeyPoints(choices, pkA, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revealed, err := ot.RevealKeyPoints(sealed, betas, skB)
+	if err == nil && revealed[1].String() == kps[restricted-1].String() {
+		t.Errorf("restricted ordinal sent in a k-out-of-n slot")
+	}
+
+	policy.Unrestrict(restricted)
+	if !policy.Allow(ot.DefaultClass, restricted) {
+		t.Errorf("unrestricted ordinal denied")
+	}
+}
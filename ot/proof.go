@@ -0,0 +1,183 @@
+package ot
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// The choice proof is a Fiat-Shamir transformed OR-composition of Chaum-Pedersen
+// proofs. For every candidate index b in 1..count it proves the statement
+//
+//   log_G(L) == log_pkB(Y - b*pkA)
+//
+// which holds for b = beta only. The receiver answers the chosen branch honestly
+// and simulates the others, so the proof shows that Y seals one index in range
+// without revealing which one.
+
+const (
+	domainChoiceProof = "proxyot/ot/choice-proof"
+
+	scalarSize = 32
+)
+
+var (
+	// ErrInvalidChoiceProof occurs when a choice proof does not verify.
+	ErrInvalidChoiceProof = errors.New("invalid choice proof")
+
+	// ErrChoiceOutOfRange occurs when the index to be sealed is not in 1..count.
+	ErrChoiceOutOfRange = errors.New("choice out of range")
+)
+
+// ChoiceProof proves that a sealed choice (Y, L) was built from an index in
+// 1..count. Cs[b-1] and Zs[b-1] are the challenge and response of index b.
+type ChoiceProof struct {
+	Cs []*big.Int
+	Zs []*big.Int
+}
+
+// SealChoiceWithProof seals beta like SealChoice, and proves that beta is in 1..count.
+func SealChoiceWithProof(beta *big.Int, pkA, pkB curve.Point, count int64) (Y, L curve.Point, proof *ChoiceProof, err error) {
+	return defaultParams.SealChoiceWithProof(beta, pkA, pkB, count)
+}
+
+// SealChoiceWithProof seals beta like SealChoice, and proves that beta is in 1..count.
+func (params *Params) SealChoiceWithProof(beta *big.Int, pkA, pkB curve.Point, count int64) (Y, L curve.Point, proof *ChoiceProof, err error) {
+	return params.sealChoiceWithProof(beta, pkA, pkB, count, nil)
+}
+
+// sealChoiceWithProof binds the proof to context if it is not nil.
+func (params *Params) sealChoiceWithProof(beta *big.Int, pkA, pkB curve.Point, count int64, context []byte) (Y, L curve.Point, proof *ChoiceProof, err error) {
+	if !beta.IsInt64() || beta.Int64() < 1 || beta.Int64() > count {
+		return nil, nil, nil, ErrChoiceOutOfRange
+	}
+	// same as SealChoice, keeping l
+	l, err := curve.RandomFieldElement(params.random())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	L = newPoint().ScalarBaseMult(l)
+	Y = newPoint().ScalarMult(pkA, beta)
+	Y.Add(Y, newPoint().ScalarMult(pkB, l))
+
+	chosen := int(beta.Int64() - 1)
+	proof = &ChoiceProof{Cs: make([]*big.Int, count), Zs: make([]*big.Int, count)}
+	commitments := make([]curve.Point, 2*count)
+	sum := new(big.Int)
+	for i := range proof.Cs {
+		if i == chosen {
+			continue
+		}
+		// simulate branch i with random challenge and response
+		if proof.Cs[i], err = curve.RandomFieldElement(params.random()); err != nil {
+			return nil, nil, nil, err
+		}
+		if proof.Zs[i], err = curve.RandomFieldElement(params.random()); err != nil {
+			return nil, nil, nil, err
+		}
+		commitments[2*i], commitments[2*i+1] = choiceCommitments(Y, L, pkA, pkB, int64(i+1), proof.Cs[i], proof.Zs[i])
+		sum.Add(sum, proof.Cs[i])
+	}
+	// chosen branch: T1 = w*G, T2 = w*pkB
+	w, err := curve.RandomFieldElement(params.random())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	commitments[2*chosen] = newPoint().ScalarBaseMult(w)
+	commitments[2*chosen+1] = newPoint().ScalarMult(pkB, w)
+
+	// c_beta = c - sum(c_i), z_beta = w + c_beta*l
+	c := choiceChallenge(Y, L, pkA, pkB, count, commitments, context)
+	proof.Cs[chosen] = c.Sub(c, sum).Mod(c, curve.Order)
+	proof.Zs[chosen] = new(big.Int).Mul(proof.Cs[chosen], l)
+	proof.Zs[chosen].Add(proof.Zs[chosen], w).Mod(proof.Zs[chosen], curve.Order)
+	return Y, L, proof, nil
+}
+
+// VerifyChoice verifies that the sealed choice (Y, L) of the receiver with
+// public key pkB was built from an index in 1..count.
+func VerifyChoice(Y, L, pkA, pkB curve.Point, count int64, proof *ChoiceProof) error {
+	return verifyChoice(Y, L, pkA, pkB, count, proof, nil)
+}
+
+func verifyChoice(Y, L, pkA, pkB curve.Point, count int64, proof *ChoiceProof, context []byte) error {
+	if proof == nil || int64(len(proof.Cs)) != count || int64(len(proof.Zs)) != count {
+		return ErrInvalidChoiceProof
+	}
+	commitments := make([]curve.Point, 2*count)
+	sum := new(big.Int)
+	for i := range proof.Cs {
+		if proof.Cs[i] == nil || proof.Zs[i] == nil || proof.Cs[i].Cmp(curve.Order) >= 0 || proof.Zs[i].Cmp(curve.Order) >= 0 {
+			return ErrInvalidChoiceProof
+		}
+		commitments[2*i], commitments[2*i+1] = choiceCommitments(Y, L, pkA, pkB, int64(i+1), proof.Cs[i], proof.Zs[i])
+		sum.Add(sum, proof.Cs[i])
+	}
+	sum.Mod(sum, curve.Order)
+	if choiceChallenge(Y, L, pkA, pkB, count, commitments, context).Cmp(sum) != 0 {
+		return ErrInvalidChoiceProof
+	}
+	return nil
+}
+
+// choiceCommitments returns T1 = z*G - c*L, T2 = z*pkB - c*(Y - ordinal*pkA).
+func choiceCommitments(Y, L, pkA, pkB curve.Point, ordinal int64, c, z *big.Int) (T1, T2 curve.Point) {
+	T1 = newPoint().ScalarBaseMult(z)
+	T1.Add(T1, newPoint().Neg(newPoint().ScalarMult(L, c)))
+
+	D := newPoint().ScalarMult(pkA, big.NewInt(ordinal))
+	D.Add(Y, D.Neg(D))
+	T2 = newPoint().ScalarMult(pkB, z)
+	T2.Add(T2, newPoint().Neg(newPoint().ScalarMult(D, c)))
+	return
+}
+
+func choiceChallenge(Y, L, pkA, pkB curve.Point, count int64, commitments []curve.Point, context []byte) *big.Int {
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(count))
+	transcript := [][]byte{pkA.Marshal(), pkB.Marshal(), Y.Marshal(), L.Marshal(), n[:]}
+	for _, T := range commitments {
+		transcript = append(transcript, T.Marshal())
+	}
+	if context != nil {
+		transcript = append(transcript, context)
+	}
+	return curve.DeriveChallenge(domainChoiceProof, transcript...)
+}
+
+// Marshal encodes the proof as:
+// count(4) || c(32) * count || z(32) * count
+func (proof *ChoiceProof) Marshal() []byte {
+	buf := make([]byte, 4+2*scalarSize*len(proof.Cs))
+	binary.BigEndian.PutUint32(buf, uint32(len(proof.Cs)))
+	offset := 4
+	for _, scalars := range [][]*big.Int{proof.Cs, proof.Zs} {
+		for _, k := range scalars {
+			b := k.Bytes()
+			copy(buf[offset+scalarSize-len(b):], b)
+			offset += scalarSize
+		}
+	}
+	return buf
+}
+
+// Unmarshal decodes a proof encoded by Marshal.
+func (proof *ChoiceProof) Unmarshal(m []byte) error {
+	if len(m) < 4 {
+		return ErrInvalidChoiceProof
+	}
+	count := uint64(binary.BigEndian.Uint32(m))
+	if m = m[4:]; uint64(len(m)) != 2*scalarSize*count {
+		return ErrInvalidChoiceProof
+	}
+	proof.Cs, proof.Zs = make([]*big.Int, count), make([]*big.Int, count)
+	for _, scalars := range [][]*big.Int{proof.Cs, proof.Zs} {
+		for i := range scalars {
+			scalars[i] = new(big.Int).SetBytes(m[:scalarSize])
+			m = m[scalarSize:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package ot_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/ot"
+)
+
+func TestChoiceProof(t *testing.T) {
+	var count int64 = 10
+
+	_, pkA, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skB, pkB, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, beta := range []int64{1, 4, count} {
+		Y, L, proof, err := ot.SealChoiceWithProof(big.NewInt(beta), pkA, pkB, count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := &ot.ChoiceProof{}
+		if err = decoded.Unmarshal(proof.Marshal()); err != nil {
+			t.Fatal(err)
+		}
+		if err = ot.VerifyChoice(Y, L, pkA, pkB, count, decoded); err != nil {
+			t.Fatalf("beta %d: %v", beta, err)
+		}
+		// the sealed choice still works as usual
+		kps, LPrime, err := ot.CalculateKeyPoints(Y, L, pkA, count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kps[beta-1].String() != ot.RevealKeyPoint(LPrime, skB).String() {
+			t.Errorf("beta %d: key point not equal", beta)
+		}
+
+		// the proof is bound to the statement
+		_, pkC, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = ot.VerifyChoice(Y, L, pkA, pkC, count, proof); !errors.Is(err, ot.ErrInvalidChoiceProof) {
+			t.Errorf("beta %d: other receiver: want ErrInvalidChoiceProof, got %v", beta, err)
+		}
+		if err = ot.VerifyChoice(L, Y, pkA, pkB, count, proof); !errors.Is(err, ot.ErrInvalidChoiceProof) {
+			t.Errorf("beta %d: swapped points: want ErrInvalidChoiceProof, got %v", beta, err)
+		}
+		if err = ot.VerifyChoice(Y, L, pkA, pkB, count-1, proof); !errors.Is(err, ot.ErrInvalidChoiceProof) {
+			t.Errorf("beta %d: other count: want ErrInvalidChoiceProof, got %v", beta, err)
+		}
+		tampered := &ot.ChoiceProof{Cs: append([]*big.Int(nil), proof.Cs...), Zs: append([]*big.Int(nil), proof.Zs...)}
+		tampered.Zs[0] = new(big.Int).Add(proof.Zs[0], big.NewInt(1))
+		if err = ot.VerifyChoice(Y, L, pkA, pkB, count, tampered); !errors.Is(err, ot.ErrInvalidChoiceProof) {
+			t.Errorf("beta %d: tampered: want ErrInvalidChoiceProof, got %v", beta, err)
+		}
+	}
+
+	// out of range choices can not be proven
+	for _, beta := range []int64{0, count + 1} {
+		if _, _, _, err = ot.SealChoiceWithProof(big.NewInt(beta), pkA, pkB, count); !errors.Is(err, ot.ErrChoiceOutOfRange) {
+			t.Errorf("beta %d: want ErrChoiceOutOfRange, got %v", beta, err)
+		}
+	}
+
+	// a proof of an in range choice does not carry over to an out of range one
+	_, _, proof, err := ot.SealChoiceWithProof(big.NewInt(1), pkA, pkB, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Y, L, err := ot.SealChoice(big.NewInt(count+1), pkA, pkB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ot.VerifyChoice(Y, L, pkA, pkB, count, proof); !errors.Is(err, ot.ErrInvalidChoiceProof) {
+		t.Errorf("out of range: want ErrInvalidChoiceProof, got %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package ot
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// A session binds an OT transaction to a random ID, the sender (owner) and
+// receiver (requester) public keys and the file set. The session hash goes
+// into the choice proof and into the keys derived from key points, so a
+// choice replayed under another session is rejected, and key points of one
+// session derive no keys of another.
+//
+// A session is only served within a time to live of its creation time. The
+// registry of served sessions forgets the expired ones, which it rejects
+// anyway, so its size stays bounded by the sessions of one time to live.
+
+const (
+	// SessionIDSize is the size of a session ID.
+	SessionIDSize = 16
+
+	// DefaultSessionTTL is the time to live of sessions in a registry created
+	// with a non-positive one.
+	DefaultSessionTTL = 10 * time.Minute
+
+	domainSession    = "proxyot/ot/session"
+	domainSessionKey = "proxyot/ot/session-key"
+)
+
+var (
+	// ErrSessionReplayed occurs when a session ID is registered twice.
+	ErrSessionReplayed = errors.New("session replayed")
+
+	// ErrInvalidSession occurs when a session is malformed, or does not match
+	// the choice it comes with.
+	ErrInvalidSession = errors.New("invalid session")
+
+	// ErrSessionExpired occurs when a session is registered out of its time to
+	// live.
+	ErrSessionExpired = errors.New("session expired")
+)
+
+// SessionID identifies an OT session.
+type SessionID [SessionIDSize]byte
+
+// String returns the hex encoding of the session ID.
+func (id SessionID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// Session is the context of an OT transaction.
+type Session struct {
+	ID        SessionID
+	Created   int64       // creation time, in unix seconds
+	Owner     curve.Point // G1 public key of the sender
+	Requester curve.Point // G1 public key of the receiver
+	FileSet   []byte      // identifier of the file set, e.g. CID bytes
+}
+
+// NewSession starts a session with a random ID.
+func NewSession(owner, requester curve.Point, fileSet []byte) (*Session, error) {
+	return defaultParams.NewSession(owner, requester, fileSet)
+}
+
+// NewSession starts a session with an ID read from params.Rand.
+func (params *Params) NewSession(owner, requester curve.Point, fileSet []byte) (*Session, error) {
+	session := &Session{
+		Created:   time.Now().Unix(),
+		Owner:     owner,
+		Requester: requester,
+		FileSet:   append([]byte(nil), fileSet...),
+	}
+	if _, err := io.ReadFull(params.random(), session.ID[:]); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Hash returns the hash of every field of the session.
+func (session *Session) Hash() [sha256.Size]byte {
+	var created [8]byte
+	binary.BigEndian.PutUint64(created[:], uint64(session.Created))
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte(domainSession), session.ID[:], created[:], session.Owner.Marshal(), session.Requester.Marshal(), session.FileSet} {
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(part)))
+		h.Write(size[:])
+		h.Write(part)
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// DeriveKey derives the receiver key of key point kp in the session, in place
+// of curve.DeriveFieldElementFromPoint(kp).
+func (session *Session) DeriveKey(kp curve.Point) *big.Int {
+	hash := session.Hash()
+	return curve.DeriveChallenge(domainSessionKey, hash[:], kp.Marshal())
+}
+
+// Check checks that the session is between owner and requester over fileSet.
+func (session *Session) Check(owner, requester curve.Point, fileSet []byte) error {
+	if session.Owner.String() != owner.String() || session.Requester.String() != requester.String() ||
+		string(session.FileSet) != string(fileSet) {
+		return ErrInvalidSession
+	}
+	return nil
+}
+
+// SealChoiceInSession seals beta like SealChoiceWithProof with pkA, pkB of the
+// session, binding the proof to the session.
+func SealChoiceInSession(session *Session, beta *big.Int, count int64) (Y, L curve.Point, proof *ChoiceProof, err error) {
+	return defaultParams.SealChoiceInSession(session, beta, count)
+}
+
+// SealChoiceInSession seals beta like SealChoiceWithProof with pkA, pkB of the
+// session, binding the proof to the session.
+func (params *Params) SealChoiceInSession(session *Session, beta *big.Int, count int64) (Y, L curve.Point, proof *ChoiceProof, err error) {
+	hash := session.Hash()
+	return params.sealChoiceWithProof(beta, session.Owner, session.Requester, count, hash[:])
+}
+
+// VerifyChoiceInSession verifies a choice sealed by SealChoiceInSession.
+func VerifyChoiceInSession(session *Session, Y, L curve.Point, count int64, proof *ChoiceProof) error {
+	hash := session.Hash()
+	return verifyChoice(Y, L, session.Owner, session.Requester, count, proof, hash[:])
+}
+
+// Marshal encodes the session as:
+// id(16) || created(8) || owner(64) || requester(64) || file_set
+func (session *Session) Marshal() []byte {
+	buf := append([]byte(nil), session.ID[:]...)
+	var created [8]byte
+	binary.BigEndian.PutUint64(created[:], uint64(session.Created))
+	buf = append(buf, created[:]...)
+	buf = append(buf, session.Owner.Marshal()...)
+	buf = append(buf, session.Requester.Marshal()...)
+	return append(buf, session.FileSet...)
+}
+
+// Unmarshal decodes a session encoded by Marshal.
+func (session *Session) Unmarshal(m []byte) (err error) {
+	if len(m) < SessionIDSize+8 {
+		return ErrInvalidSession
+	}
+	var id SessionID
+	copy(id[:], m)
+	created := int64(binary.BigEndian.Uint64(m[SessionIDSize:]))
+	owner, requester := newPoint(), newPoint()
+	if m, err = owner.Unmarshal(m[SessionIDSize+8:]); err != nil {
+		return err
+	}
+	if m, err = requester.Unmarshal(m); err != nil {
+		return err
+	}
+	session.ID, session.Created, session.Owner, session.Requester = id, created, owner, requester
+	session.FileSet = append([]byte(nil), m...)
+	return nil
+}
+
+// SessionRegistry records the sessions a sender has served, to reject replays.
+// It holds every session until its time to live is over.
+type SessionRegistry struct {
+	// Now is the clock of the registry, time.Now is used if nil.
+	Now func() time.Time
+
+	l       sync.Mutex
+	ttl     time.Duration
+	seen    map[SessionID]time.Time // expiry of every session
+	pruneAt time.Time
+}
+
+// NewSessionRegistry creates an empty registry of sessions living ttl, or
+// DefaultSessionTTL if ttl is not positive.
+func NewSessionRegistry(ttl time.Duration) *SessionRegistry {
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	return &SessionRegistry{ttl: ttl, seen: make(map[SessionID]time.Time)}
+}
+
+// Register records the session. It returns ErrSessionExpired if the session
+// was created more than ttl ago, or ttl ahead for skewed clocks, and
+// ErrSessionReplayed if its ID is registered.
+func (registry *SessionRegistry) Register(session *Session) error {
+	now := registry.now()
+	created := time.Unix(session.Created, 0)
+	if now.Sub(created) > registry.ttl || created.Sub(now) > registry.ttl {
+		return ErrSessionExpired
+	}
+	registry.l.Lock()
+	defer registry.l.Unlock()
+	if now.After(registry.pruneAt) {
+		for id, expiry := range registry.seen {
+			if now.After(expiry) {
+				delete(registry.seen, id)
+			}
+		}
+		registry.pruneAt = now.Add(registry.ttl)
+	}
+	if _, ok := registry.seen[session.ID]; ok {
+		return ErrSessionReplayed
+	}
+	registry.seen[session.ID] = created.Add(registry.ttl)
+	return nil
+}
+
+func (registry *SessionRegistry) now() time.Time {
+	if registry.Now == nil {
+		return time.Now()
+	}
+	return registry.Now()
+}
+
+// Len returns the count of sessions held by the registry.
+func (registry *SessionRegistry) Len() int {
+	registry.l.Lock()
+	defer registry.l.Unlock()
+	return len(registry.seen)
+}
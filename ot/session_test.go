@@ -0,0 +1,140 @@
+package ot_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/ot"
+)
+
+func TestSession(t *testing.T) {
+	var count int64 = 8
+	var beta int64 = 5
+	fileSet := []byte("QmQTw94j68Dgakgtfd45bG3TZG6CAfc427UVRH4mugg4q4")
+
+	_, pkA, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skB, pkB, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// receiver starts a session and seals its choice in it
+	session, err := ot.NewSession(pkA, pkB, fileSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Y, L, proof, err := ot.SealChoiceInSession(session, big.NewInt(beta), count)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sender decodes the session, checks it and serves it once
+	received := &ot.Session{}
+	if err = received.Unmarshal(session.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	if received.Hash() != session.Hash() {
+		t.Fatalf("session hash mismatch")
+	}
+	if err = received.Check(pkA, pkB, fileSet); err != nil {
+		t.Fatal(err)
+	}
+	if err = received.Check(pkA, pkB, []byte("other")); !errors.Is(err, ot.ErrInvalidSession) {
+		t.Errorf("other file set: want ErrInvalidSession, got %v", err)
+	}
+	if err = ot.VerifyChoiceInSession(received, Y, L, count, proof); err != nil {
+		t.Fatal(err)
+	}
+	registry := ot.NewSessionRegistry(0)
+	if err = registry.Register(received); err != nil {
+		t.Fatal(err)
+	}
+	if err = registry.Register(received); !errors.Is(err, ot.ErrSessionReplayed) {
+		t.Errorf("replay: want ErrSessionReplayed, got %v", err)
+	}
+
+	// a replayed choice does not verify under a fresh session
+	fresh, err := ot.NewSession(pkA, pkB, fileSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ot.VerifyChoiceInSession(fresh, Y, L, count, proof); !errors.Is(err, ot.ErrInvalidChoiceProof) {
+		t.Errorf("fresh session: want ErrInvalidChoiceProof, got %v", err)
+	}
+
+	// keys are derived in the session
+	kps, LPrime, err := ot.CalculateKeyPoints(Y, L, pkA, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kpB := ot.RevealKeyPoint(LPrime, skB)
+	if received.DeriveKey(kps[beta-1]).Cmp(session.DeriveKey(kpB)) != 0 {
+		t.Errorf("session keys not equal")
+	}
+	if fresh.DeriveKey(kpB).Cmp(session.DeriveKey(kpB)) == 0 {
+		t.Errorf("sessions derive the same key")
+	}
+	if session.DeriveKey(kpB).Cmp(curve.DeriveFieldElementFromPoint(kpB)) == 0 {
+		t.Errorf("session key equals the context free key")
+	}
+}
+
+func TestSessionRegistryExpiry(t *testing.T) {
+	_, pkA, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, pkB, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	registry := ot.NewSessionRegistry(time.Hour)
+	registry.Now = func() time.Time { return now }
+
+	// sessions out of the time to live are rejected, not recorded
+	for _, created := range []time.Time{now.Add(-2 * time.Hour), now.Add(2 * time.Hour)} {
+		session, err := ot.NewSession(pkA, pkB, []byte("files"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		session.Created = created.Unix()
+		if err = registry.Register(session); !errors.Is(err, ot.ErrSessionExpired) {
+			t.Errorf("created %v: want ErrSessionExpired, got %v", created, err)
+		}
+	}
+	if registry.Len() != 0 {
+		t.Errorf("want an empty registry, got %d sessions", registry.Len())
+	}
+
+	// expired sessions are forgotten
+	for i := 0; i < 3; i++ {
+		session, err := ot.NewSession(pkA, pkB, []byte("files"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		session.Created = now.Unix()
+		if err = registry.Register(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now = now.Add(90 * time.Minute)
+	session, err := ot.NewSession(pkA, pkB, []byte("files"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Created = now.Unix()
+	if err = registry.Register(session); err != nil {
+		t.Fatal(err)
+	}
+	if registry.Len() != 1 {
+		t.Errorf("want 1 live session, got %d", registry.Len())
+	}
+}
@@ -0,0 +1,74 @@
+package ot
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// KeyPointStream yields the key points of StreamKeyPoints.
+type KeyPointStream struct {
+	KeyPoints <-chan curve.Point // closed after the last key point, see Err
+	LPrime    curve.Point
+
+	err error
+}
+
+// Err returns the error that closed KeyPoints early, once KeyPoints is
+// closed: ctx.Err(), or the error reading randomness for an ordinal denied by
+// the policy. It returns nil once every key point was yielded.
+func (stream *KeyPointStream) Err() error {
+	return stream.err
+}
+
+// StreamKeyPoints calculates key points like CalculateKeyPoints, but yields
+// them one at a time in the order of their ordinals, so that memory stays
+// bounded by the consumer. KeyPoints is closed after the last key point, or
+// early if ctx is done or no randomness is left for a denied ordinal; the
+// consumer tells both apart by Err.
+func StreamKeyPoints(ctx context.Context, Y, L, pkA curve.Point, count int64) (stream *KeyPointStream, err error) {
+	return defaultParams.StreamKeyPoints(ctx, Y, L, pkA, count)
+}
+
+// StreamKeyPoints calculates key points like CalculateKeyPoints, but yields
+// them one at a time in the order of their ordinals, so that memory stays
+// bounded by the consumer. KeyPoints is closed after the last key point, or
+// early if ctx is done or no randomness is left for a denied ordinal; the
+// consumer tells both apart by Err.
+func (params *Params) StreamKeyPoints(ctx context.Context, Y, L, pkA curve.Point, count int64) (stream *KeyPointStream, err error) {
+	// random number t
+	var t *big.Int
+	t, err = curve.RandomFieldElement(params.random())
+	if err != nil {
+		return
+	}
+	ch := make(chan curve.Point)
+	// calculate LPrime = t * L
+	stream = &KeyPointStream{KeyPoints: ch, LPrime: newPoint().ScalarMult(L, t)}
+	go func() {
+		defer close(ch)
+		// kp_i = kp_{i-1} - t * pkA, kp_0 = t * Y
+		kp := newPoint().ScalarMult(Y, t)
+		step := newPoint().ScalarMult(pkA, t)
+		step.Neg(step)
+		for i := int64(1); i <= count; i++ {
+			if stream.err = ctx.Err(); stream.err != nil {
+				return
+			}
+			kp = newPoint().Add(kp, step)
+			// the consumer owns the yielded point, kp is read by the next step
+			var out curve.Point
+			if out, stream.err = params.applyPolicy(DefaultClass, i, newPoint().Set(kp)); stream.err != nil {
+				return
+			}
+			select {
+			case ch <- out:
+			case <-ctx.Done():
+				stream.err = ctx.Err()
+				return
+			}
+		}
+	}()
+	return stream, nil
+}
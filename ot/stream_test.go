@@ -0,0 +1,87 @@
+package ot_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/ot"
+)
+
+func TestStreamKeyPoints(t *testing.T) {
+	var count int64 = 30
+
+	_, pkA, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, pkB, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Y, L, err := ot.SealChoice(big.NewInt(9), pkA, pkB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := randomScalar(t)
+
+	want, wantLPrime, err := (&ot.Params{Rand: bytes.NewReader(secret)}).CalculateKeyPoints(Y, L, pkA, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := (&ot.Params{Rand: bytes.NewReader(secret)}).StreamKeyPoints(context.Background(), Y, L, pkA, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stream.LPrime.String() != wantLPrime.String() {
+		t.Errorf("LPrime mismatch")
+	}
+	var i int
+	for kp := range stream.KeyPoints {
+		if i >= len(want) {
+			t.Fatalf("more than %d key points", count)
+		}
+		if kp.String() != want[i].String() {
+			t.Errorf("key point %d mismatch", i+1)
+		}
+		i++
+	}
+	if i != len(want) {
+		t.Errorf("want %d key points, got %d", count, i)
+	}
+	if err = stream.Err(); err != nil {
+		t.Errorf("complete stream: %v", err)
+	}
+
+	// the stream stops once ctx is done
+	ctx, cancel := context.WithCancel(context.Background())
+	if stream, err = ot.StreamKeyPoints(ctx, Y, L, pkA, count); err != nil {
+		t.Fatal(err)
+	}
+	<-stream.KeyPoints
+	cancel()
+	for range stream.KeyPoints {
+	}
+	if err = stream.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled stream: want context.Canceled, got %v", err)
+	}
+
+	// a denied ordinal without randomness left truncates the stream visibly
+	policy := ot.NewAccessPolicy()
+	policy.Restrict(5)
+	params := &ot.Params{Rand: bytes.NewReader(randomScalar(t)), Policy: policy}
+	if stream, err = params.StreamKeyPoints(context.Background(), Y, L, pkA, count); err != nil {
+		t.Fatal(err)
+	}
+	i = 0
+	for range stream.KeyPoints {
+		i++
+	}
+	if i != 4 || stream.Err() == nil {
+		t.Errorf("want 4 key points and an error, got %d and %v", i, stream.Err())
+	}
+}
@@ -0,0 +1,142 @@
+package ot_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/ot"
+)
+
+var update = flag.Bool("update", false, "regenerate testdata/vectors.json")
+
+const vectorsFile = "testdata/vectors.json"
+
+// otVectors are the known-answer transcripts of OT. Scalars are 32-byte big
+// endian, points are curve.Point.Marshal(), all hex encoded. SealChoice reads
+// l and CalculateKeyPoints reads t from their randomness sources.
+type otVectors struct {
+	Comment string     `json:"comment"`
+	Vectors []otVector `json:"vectors"`
+}
+
+type otVector struct {
+	SkA       string   `json:"sk_a"`
+	SkB       string   `json:"sk_b"`
+	Beta      int64    `json:"beta"`
+	Count     int64    `json:"count"`
+	L         string   `json:"l"`
+	T         string   `json:"t"`
+	PkA       string   `json:"pk_a"`
+	PkB       string   `json:"pk_b"`
+	YPoint    string   `json:"y_point"`        // Y = beta * pkA + l * pkB
+	LPoint    string   `json:"l_point"`        // L = l * G1
+	KeyPoints []string `json:"key_points"`     // kp_i = t * Y - i * t * pkA
+	LPrime    string   `json:"l_prime"`        // L' = t * L
+	Revealed  string   `json:"revealed_point"` // skB * L'
+}
+
+func TestVectors(t *testing.T) {
+	if *update {
+		writeVectors(t)
+	}
+	data, err := ioutil.ReadFile(vectorsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors otVectors
+	if err = json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors.Vectors) == 0 {
+		t.Fatal("no vectors")
+	}
+	for i, v := range vectors.Vectors {
+		got, err := computeVector(decodeHex(t, v.SkA), decodeHex(t, v.SkB), v.Beta, v.Count, decodeHex(t, v.L), decodeHex(t, v.T))
+		if err != nil {
+			t.Fatalf("vector %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(*got, v) {
+			t.Errorf("vector %d: mismatch\nwant: %+v\ngot:  %+v", i, v, *got)
+		}
+		if v.KeyPoints[v.Beta-1] != v.Revealed {
+			t.Errorf("vector %d: revealed point is not the chosen key point", i)
+		}
+	}
+}
+
+func computeVector(skA, skB []byte, beta, count int64, l, t []byte) (*otVector, error) {
+	pkA := curve.NewPoint(curve.TypeG1).ScalarBaseMult(new(big.Int).SetBytes(skA))
+	pkB := curve.NewPoint(curve.TypeG1).ScalarBaseMult(new(big.Int).SetBytes(skB))
+	receiver := &ot.Params{Rand: bytes.NewReader(l)}
+	Y, L, err := receiver.SealChoice(big.NewInt(beta), pkA, pkB)
+	if err != nil {
+		return nil, err
+	}
+	sender := &ot.Params{Rand: bytes.NewReader(t)}
+	kps, LPrime, err := sender.CalculateKeyPoints(Y, L, pkA, count)
+	if err != nil {
+		return nil, err
+	}
+	v := &otVector{
+		SkA:      hex.EncodeToString(skA),
+		SkB:      hex.EncodeToString(skB),
+		Beta:     beta,
+		Count:    count,
+		L:        hex.EncodeToString(l),
+		T:        hex.EncodeToString(t),
+		PkA:      hex.EncodeToString(pkA.Marshal()),
+		PkB:      hex.EncodeToString(pkB.Marshal()),
+		YPoint:   hex.EncodeToString(Y.Marshal()),
+		LPoint:   hex.EncodeToString(L.Marshal()),
+		LPrime:   hex.EncodeToString(LPrime.Marshal()),
+		Revealed: hex.EncodeToString(ot.RevealKeyPoint(LPrime, new(big.Int).SetBytes(skB)).Marshal()),
+	}
+	for _, kp := range kps {
+		v.KeyPoints = append(v.KeyPoints, hex.EncodeToString(kp.Marshal()))
+	}
+	return v, nil
+}
+
+func writeVectors(t *testing.T) {
+	vectors := otVectors{
+		Comment: "scalars are 32-byte big endian, points are Marshal() encoded, SealChoice reads l, CalculateKeyPoints reads t",
+	}
+	for _, choice := range [][2]int64{{1, 1}, {3, 5}, {10, 10}} {
+		v, err := computeVector(randomScalar(t), randomScalar(t), choice[0], choice[1], randomScalar(t), randomScalar(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		vectors.Vectors = append(vectors.Vectors, *v)
+	}
+	data, err := json.MarshalIndent(&vectors, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(vectorsFile, append(data, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func randomScalar(t *testing.T) []byte {
+	k, err := curve.RandomFieldElement(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(make([]byte, 32-len(k.Bytes())), k.Bytes()...)
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
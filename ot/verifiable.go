@@ -0,0 +1,235 @@
+package ot
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// In the verifiable mode the sender commits to t * pkA and t * Y by pairing
+// them with the G2 generator, V = e(t * pkA, G2) and W = e(t * Y, G2), and
+// proves with a Chaum-Pedersen (DLEQ) proof that LPrime, V and W use the same
+// t. The commitment of ordinal i, e(kp_i, G2) = W - i * V, is then fixed for
+// every ordinal at once: the sender can not commit to key points of another t
+// for some ordinals only, and whoever holds kp_i, the receiver of beta or an
+// arbiter, checks it against its ordinal.
+//
+// The pairing hides the G1 points. With t * pkA in clear the receiver, who
+// knows kp_beta and beta, would compute t * Y = kp_beta + beta * t * pkA and
+// then every other key point, defeating the OT.
+//
+// The proof covers the key points, not what the sender derives from them. An
+// honest receiver always opens its commitment, even when the re-key sent for
+// its ordinal decrypts nothing. The re-keys are bound to the key points by
+// VerifyReKey, for whoever holds the re-key of an ordinal, and by AuditReKeys,
+// for an arbiter holding every re-key and t. A receiver seeing only the
+// re-encrypted capsule checks it with pre.VerifyReEncryption against
+// pre.ReceiverReKeyCommitment of its key.
+
+const domainKeyPointsProof = "proxyot/ot/key-points-proof"
+
+var (
+	// ErrInvalidKeyPointsProof occurs when the DLEQ proof of the key points does not verify.
+	ErrInvalidKeyPointsProof = errors.New("invalid key points proof")
+
+	// ErrKeyPointMismatch occurs when a key point does not open its commitment.
+	ErrKeyPointMismatch = errors.New("key point does not match commitment")
+
+	// ErrReKeyMismatch occurs when a re-key was not generated for the key of its
+	// key point.
+	ErrReKeyMismatch = errors.New("re-key does not match key point")
+)
+
+// KeyPointsProof is sent with LPrime to the receiver in the verifiable mode.
+type KeyPointsProof struct {
+	V         curve.Point // V = e(t * pkA, G2), commits to t * pkA
+	W         curve.Point // W = e(t * Y, G2), commits to t * Y
+	Challenge *big.Int
+	Response  *big.Int // Response = w + Challenge * t
+}
+
+// CalculateKeyPointsWithProof calculates key points like CalculateKeyPoints,
+// and proves they were calculated with the t of LPrime. The sender keeps t
+// secret, to open it to an arbiter in AuditKeyPoints.
+func CalculateKeyPointsWithProof(Y, L, pkA curve.Point, count int64) (kps []curve.Point, LPrime curve.Point, proof *KeyPointsProof, t *big.Int, err error) {
+	return defaultParams.CalculateKeyPointsWithProof(Y, L, pkA, count)
+}
+
+// CalculateKeyPointsWithProof calculates key points like CalculateKeyPoints,
+// and proves they were calculated with the t of LPrime. The sender keeps t
+// secret, to open it to an arbiter in AuditKeyPoints.
+//
+// The key points of ordinals denied by params.Policy are replaced as in
+// CalculateKeyPointsForClass. The proof covers the key points of t: the
+// receiver of a denied ordinal verifies the key point it reveals, but its
+// re-key fails VerifyReKey, and AuditReKeys reports the ordinal.
+func (params *Params) CalculateKeyPointsWithProof(Y, L, pkA curve.Point, count int64) (kps []curve.Point, LPrime curve.Point, proof *KeyPointsProof, t *big.Int, err error) {
+	pairedA, pairedY, ok := pairKeyPointBases(Y, L, pkA)
+	if !ok {
+		return nil, nil, nil, nil, ErrInvalidKeyPointsProof
+	}
+	if t, err = curve.RandomFieldElement(params.random()); err != nil {
+		return
+	}
+	kps = params.calculateKeyPoints(t, Y, pkA, count)
+	for i := range kps {
+		if kps[i], err = params.applyPolicy(DefaultClass, int64(i+1), kps[i]); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+	LPrime = newPoint().ScalarMult(L, t)
+
+	proof = &KeyPointsProof{
+		V: curve.NewPoint(curve.TypeGT).ScalarMult(pairedA, t),
+		W: curve.NewPoint(curve.TypeGT).ScalarMult(pairedY, t),
+	}
+	// DLEQ: T1 = w*L, T2 = w*e(pkA, G2), T3 = w*e(Y, G2)
+	var w *big.Int
+	if w, err = curve.RandomFieldElement(params.random()); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	T1 := newPoint().ScalarMult(L, w)
+	T2 := curve.NewPoint(curve.TypeGT).ScalarMult(pairedA, w)
+	T3 := curve.NewPoint(curve.TypeGT).ScalarMult(pairedY, w)
+	proof.Challenge = keyPointsChallenge(Y, L, pkA, LPrime, proof, T1, T2, T3)
+	proof.Response = new(big.Int).Mul(proof.Challenge, t)
+	proof.Response.Add(proof.Response, w).Mod(proof.Response, curve.Order)
+	return
+}
+
+// VerifyKeyPoint verifies the proof of the sealed choice (Y, L), and that kp,
+// the key point revealed from LPrime, opens the commitment of ordinal beta.
+func VerifyKeyPoint(Y, L, pkA, LPrime curve.Point, proof *KeyPointsProof, beta int64, kp curve.Point) error {
+	if err := verifyKeyPointsProof(Y, L, pkA, LPrime, proof); err != nil {
+		return err
+	}
+	if beta < 1 {
+		return ErrChoiceOutOfRange
+	}
+	kpG1, ok := kp.(*curve.G1)
+	if !ok || kpG1 == nil {
+		return ErrKeyPointMismatch
+	}
+	// e(kp, G2) = W - beta * V
+	commitment := curve.NewPoint(curve.TypeGT).ScalarMult(proof.V, big.NewInt(beta))
+	commitment.Add(proof.W, curve.NewPoint(curve.TypeGT).Neg(commitment))
+	if curve.Pair(kpG1, g2Generator()).String() != commitment.String() {
+		return ErrKeyPointMismatch
+	}
+	return nil
+}
+
+// AuditKeyPoints verifies the proof, and that the opened t is the one it
+// commits to, so that every key point is kp_i = t * Y - i * t * pkA.
+func AuditKeyPoints(Y, L, pkA, LPrime curve.Point, proof *KeyPointsProof, t *big.Int) error {
+	if err := verifyKeyPointsProof(Y, L, pkA, LPrime, proof); err != nil {
+		return err
+	}
+	pairedA, _, _ := pairKeyPointBases(Y, L, pkA)
+	if curve.NewPoint(curve.TypeGT).ScalarMult(pairedA, t).String() != proof.V.String() {
+		return ErrInvalidKeyPointsProof
+	}
+	return nil
+}
+
+// VerifyReKey checks that rk, the re-key sent for an ordinal, was generated by
+// pre.GenerateReKey for key, the receiver key derived from the key point of
+// the ordinal: e(pkA, rk) = key * e(G1, G2).
+func VerifyReKey(pkA, rk curve.Point, key *big.Int) error {
+	if !isG1(pkA) || !isG2(rk) || key == nil {
+		return ErrReKeyMismatch
+	}
+	expected := curve.NewPoint(curve.TypeGT).ScalarMult(oneGT, key)
+	if curve.Pair(pkA.(*curve.G1), rk.(*curve.G2)).String() != expected.String() {
+		return ErrReKeyMismatch
+	}
+	return nil
+}
+
+// AuditReKeys audits the key points like AuditKeyPoints, then checks that
+// rks[i] was generated for the key that deriveKey, curve.DeriveFieldElementFromPoint
+// or Session.DeriveKey, derives from kp_{i+1}. It returns the ordinals whose
+// re-keys do not match, the ordinals denied by a policy among them.
+func AuditReKeys(Y, L, pkA, LPrime curve.Point, proof *KeyPointsProof, t *big.Int, rks []curve.Point, deriveKey func(kp curve.Point) *big.Int) (mismatched []int64, err error) {
+	if err = AuditKeyPoints(Y, L, pkA, LPrime, proof, t); err != nil {
+		return nil, err
+	}
+	kps := defaultParams.calculateKeyPoints(t, Y, pkA, int64(len(rks)))
+	for i := range rks {
+		if VerifyReKey(pkA, rks[i], deriveKey(kps[i])) != nil {
+			mismatched = append(mismatched, int64(i+1))
+		}
+	}
+	return mismatched, nil
+}
+
+func verifyKeyPointsProof(Y, L, pkA, LPrime curve.Point, proof *KeyPointsProof) error {
+	if proof == nil || proof.Challenge == nil || proof.Response == nil {
+		return ErrInvalidKeyPointsProof
+	}
+	if !isGT(proof.V) || !isGT(proof.W) || !isG1(LPrime) {
+		return ErrInvalidKeyPointsProof
+	}
+	pairedA, pairedY, ok := pairKeyPointBases(Y, L, pkA)
+	if !ok {
+		return ErrInvalidKeyPointsProof
+	}
+	// T1 = Response*L - Challenge*LPrime, T2 = Response*e(pkA, G2) - Challenge*V,
+	// T3 = Response*e(Y, G2) - Challenge*W
+	T1 := newPoint().ScalarMult(L, proof.Response)
+	T1.Add(T1, newPoint().Neg(newPoint().ScalarMult(LPrime, proof.Challenge)))
+	T2 := curve.NewPoint(curve.TypeGT).ScalarMult(pairedA, proof.Response)
+	T2.Add(T2, curve.NewPoint(curve.TypeGT).Neg(curve.NewPoint(curve.TypeGT).ScalarMult(proof.V, proof.Challenge)))
+	T3 := curve.NewPoint(curve.TypeGT).ScalarMult(pairedY, proof.Response)
+	T3.Add(T3, curve.NewPoint(curve.TypeGT).Neg(curve.NewPoint(curve.TypeGT).ScalarMult(proof.W, proof.Challenge)))
+	if keyPointsChallenge(Y, L, pkA, LPrime, proof, T1, T2, T3).Cmp(proof.Challenge) != 0 {
+		return ErrInvalidKeyPointsProof
+	}
+	return nil
+}
+
+// pairKeyPointBases returns e(pkA, G2) and e(Y, G2), and whether Y, L and pkA
+// are G1 points.
+func pairKeyPointBases(Y, L, pkA curve.Point) (pairedA, pairedY curve.Point, ok bool) {
+	if !isG1(Y) || !isG1(L) || !isG1(pkA) {
+		return nil, nil, false
+	}
+	g2 := g2Generator()
+	return curve.Pair(pkA.(*curve.G1), g2), curve.Pair(Y.(*curve.G1), g2), true
+}
+
+func keyPointsChallenge(Y, L, pkA, LPrime curve.Point, proof *KeyPointsProof, T1, T2, T3 curve.Point) *big.Int {
+	return curve.DeriveChallenge(domainKeyPointsProof, Y.Marshal(), L.Marshal(), pkA.Marshal(), LPrime.Marshal(),
+		proof.V.Marshal(), proof.W.Marshal(), T1.Marshal(), T2.Marshal(), T3.Marshal())
+}
+
+// Marshal encodes the proof as:
+// V(384) || W(384) || challenge(32) || response(32)
+func (proof *KeyPointsProof) Marshal() []byte {
+	buf := append(proof.V.Marshal(), proof.W.Marshal()...)
+	for _, k := range []*big.Int{proof.Challenge, proof.Response} {
+		b := k.Bytes()
+		buf = append(buf, make([]byte, scalarSize-len(b))...)
+		buf = append(buf, b...)
+	}
+	return buf
+}
+
+// Unmarshal decodes a proof encoded by Marshal.
+func (proof *KeyPointsProof) Unmarshal(m []byte) (err error) {
+	V, W := curve.NewPoint(curve.TypeGT), curve.NewPoint(curve.TypeGT)
+	if m, err = V.Unmarshal(m); err != nil {
+		return err
+	}
+	if m, err = W.Unmarshal(m); err != nil {
+		return err
+	}
+	if len(m) != 2*scalarSize {
+		return ErrInvalidKeyPointsProof
+	}
+	proof.V, proof.W = V, W
+	proof.Challenge = new(big.Int).SetBytes(m[:scalarSize])
+	proof.Response = new(big.Int).SetBytes(m[scalarSize:])
+	return nil
+}
@@ -0,0 +1,152 @@
+package ot_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/ot"
+	"github.com/clarenous/proxyot/pre"
+)
+
+func TestVerifiableKeyPoints(t *testing.T) {
+	var count int64 = 20
+	var beta int64 = 7
+
+	_, pkA, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skB, pkB, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Y, L, err := ot.SealChoice(big.NewInt(beta), pkA, pkB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kps, LPrime, proof, secret, err := ot.CalculateKeyPointsWithProof(Y, L, pkA, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &ot.KeyPointsProof{}
+	if err = decoded.Unmarshal(proof.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+
+	// receiver verifies its key point
+	kpB := ot.RevealKeyPoint(LPrime, skB)
+	if kps[beta-1].String() != kpB.String() {
+		t.Fatalf("key point not equal")
+	}
+	if err = ot.VerifyKeyPoint(Y, L, pkA, LPrime, decoded, beta, kpB); err != nil {
+		t.Fatal(err)
+	}
+	if err = ot.VerifyKeyPoint(Y, L, pkA, LPrime, decoded, beta+1, kpB); !errors.Is(err, ot.ErrKeyPointMismatch) {
+		t.Errorf("other ordinal: want ErrKeyPointMismatch, got %v", err)
+	}
+
+	// the commitments of every ordinal are fixed by the proof, so a key point
+	// of another t does not open any of them
+	for i := range kps {
+		if err = ot.VerifyKeyPoint(Y, L, pkA, LPrime, decoded, int64(i+1), kps[i]); err != nil {
+			t.Fatalf("ordinal %d: %v", i+1, err)
+		}
+	}
+	otherKps, _, err := ot.CalculateKeyPoints(Y, L, pkA, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ot.VerifyKeyPoint(Y, L, pkA, LPrime, decoded, 3, otherKps[2]); !errors.Is(err, ot.ErrKeyPointMismatch) {
+		t.Errorf("key point of another t: want ErrKeyPointMismatch, got %v", err)
+	}
+
+	// arbiter audits the commitments with the opened t
+	if err = ot.AuditKeyPoints(Y, L, pkA, LPrime, decoded, secret); err != nil {
+		t.Fatal(err)
+	}
+	if err = ot.AuditKeyPoints(Y, L, pkA, LPrime, decoded, new(big.Int).Add(secret, big.NewInt(1))); !errors.Is(err, ot.ErrInvalidKeyPointsProof) {
+		t.Errorf("wrong opening: want ErrInvalidKeyPointsProof, got %v", err)
+	}
+
+	// LPrime from another t is detected
+	otherLPrime := curve.NewPoint(curve.TypeG1).ScalarMult(L, big.NewInt(2))
+	if err = ot.VerifyKeyPoint(Y, L, pkA, otherLPrime, decoded, beta, kpB); !errors.Is(err, ot.ErrInvalidKeyPointsProof) {
+		t.Errorf("other LPrime: want ErrInvalidKeyPointsProof, got %v", err)
+	}
+
+	// commitments are bound to the proof
+	tampered := &ot.KeyPointsProof{}
+	if err = tampered.Unmarshal(proof.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	tampered.W = curve.NewPoint(curve.TypeGT).Add(tampered.W, tampered.V)
+	if err = ot.VerifyKeyPoint(Y, L, pkA, LPrime, tampered, beta, kpB); !errors.Is(err, ot.ErrInvalidKeyPointsProof) {
+		t.Errorf("tampered commitment: want ErrInvalidKeyPointsProof, got %v", err)
+	}
+}
+
+func TestVerifiableReKeys(t *testing.T) {
+	var count int64 = 8
+	var beta, denied int64 = 3, 5
+
+	a, pkA, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skB, pkB, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Y, L, err := ot.SealChoice(big.NewInt(beta), pkA, pkB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := ot.NewAccessPolicy()
+	policy.Restrict(denied)
+	kps, LPrime, proof, secret, err := (&ot.Params{Policy: policy}).CalculateKeyPointsWithProof(Y, L, pkA, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rks := make([]curve.Point, count)
+	for i := range kps {
+		rks[i] = pre.GenerateReKey(a, curve.DeriveFieldElementFromPoint(kps[i]))
+	}
+
+	// the receiver binds the re-key of its ordinal to its key point
+	kpB := ot.RevealKeyPoint(LPrime, skB)
+	if err = ot.VerifyKeyPoint(Y, L, pkA, LPrime, proof, beta, kpB); err != nil {
+		t.Fatal(err)
+	}
+	if err = ot.VerifyReKey(pkA, rks[beta-1], curve.DeriveFieldElementFromPoint(kpB)); err != nil {
+		t.Fatal(err)
+	}
+
+	// a re-key that decrypts nothing passes VerifyKeyPoint, but not VerifyReKey
+	wrong := append([]curve.Point(nil), rks...)
+	wrong[beta-1] = rks[beta]
+	if err = ot.VerifyReKey(pkA, wrong[beta-1], curve.DeriveFieldElementFromPoint(kpB)); !errors.Is(err, ot.ErrReKeyMismatch) {
+		t.Errorf("wrong re-key: want ErrReKeyMismatch, got %v", err)
+	}
+	if err = ot.VerifyReKey(pkA, (*curve.G2)(nil), curve.DeriveFieldElementFromPoint(kpB)); !errors.Is(err, ot.ErrReKeyMismatch) {
+		t.Errorf("typed-nil re-key: want ErrReKeyMismatch, got %v", err)
+	}
+
+	// the arbiter reports the denied ordinal and the wrong re-key
+	mismatched, err := ot.AuditReKeys(Y, L, pkA, LPrime, proof, secret, rks, curve.DeriveFieldElementFromPoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatched) != 1 || mismatched[0] != denied {
+		t.Errorf("want ordinal %d mismatched, got %v", denied, mismatched)
+	}
+	if mismatched, err = ot.AuditReKeys(Y, L, pkA, LPrime, proof, secret, wrong, curve.DeriveFieldElementFromPoint); err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatched) != 2 || mismatched[0] != beta || mismatched[1] != denied {
+		t.Errorf("want ordinals %d and %d mismatched, got %v", beta, denied, mismatched)
+	}
+}
@@ -0,0 +1,77 @@
+package pre
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+var (
+	// ErrLengthMismatch occurs when the count of capsules and re-keys differ.
+	ErrLengthMismatch = errors.New("capsules and re-keys length mismatch")
+
+	errInvalidCapsulePoint = errors.New("capsule is not a G1 point")
+	errInvalidReKeyPoint   = errors.New("re-key is not a G2 point")
+)
+
+// ReEncryptBatch re-encrypts capsules[i] with reKeys[i] on at most workers
+// goroutines, or on runtime.GOMAXPROCS(0) goroutines if workers < 1.
+//
+// errs[i] reports the failure of item i. If ctx is done before every item is
+// scheduled, the unscheduled items fail with ctx.Err(), which is also
+// returned as err.
+func ReEncryptBatch(ctx context.Context, capsules, reKeys []curve.Point, workers int) (APrimes []curve.Point, errs []error, err error) {
+	if len(capsules) != len(reKeys) {
+		return nil, nil, ErrLengthMismatch
+	}
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	APrimes = make([]curve.Point, len(capsules))
+	errs = make([]error, len(capsules))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				APrimes[i], errs[i] = reEncryptItem(capsules[i], reKeys[i])
+			}
+		}()
+	}
+
+	scheduled := 0
+schedule:
+	for ; scheduled < len(capsules) && ctx.Err() == nil; scheduled++ {
+		select {
+		case <-ctx.Done():
+			break schedule
+		case jobs <- scheduled:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if scheduled < len(capsules) {
+		err = ctx.Err()
+		for i := scheduled; i < len(capsules); i++ {
+			errs[i] = err
+		}
+	}
+	return APrimes, errs, err
+}
+
+func reEncryptItem(A, rkAB curve.Point) (curve.Point, error) {
+	if !isG1(A) {
+		return nil, errInvalidCapsulePoint
+	}
+	if !isG2(rkAB) {
+		return nil, errInvalidReKeyPoint
+	}
+	return ReEncrypt(A, rkAB), nil
+}
@@ -0,0 +1,78 @@
+package pre_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/pre"
+)
+
+func TestReEncryptBatch(t *testing.T) {
+	var count = 20
+
+	capsules := make([]curve.Point, count)
+	reKeys := make([]curve.Point, count)
+	for i := range capsules {
+		a, pkA, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := curve.RandomFieldElement(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := curve.RandomFieldElement(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		capsules[i] = curve.NewPoint(curve.TypeG1).ScalarMult(pkA, r)
+		reKeys[i] = pre.GenerateReKey(a, b)
+	}
+	// malformed items fail alone
+	reKeys[3] = curve.NewPoint(curve.TypeG1).ScalarBaseMult(curve.Order)
+	capsules[5] = nil
+	capsules[7] = (*curve.G1)(nil)
+
+	for _, workers := range []int{0, 1, 4, 64} {
+		APrimes, errs, err := pre.ReEncryptBatch(context.Background(), capsules, reKeys, workers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range capsules {
+			if i == 3 || i == 5 || i == 7 {
+				if errs[i] == nil {
+					t.Errorf("workers %d: item %d: want error", workers, i)
+				}
+				continue
+			}
+			if errs[i] != nil {
+				t.Fatalf("workers %d: item %d: %v", workers, i, errs[i])
+			}
+			want := pre.ReEncrypt(capsules[i], reKeys[i])
+			if !bytes.Equal(want.Marshal(), APrimes[i].Marshal()) {
+				t.Errorf("workers %d: item %d: re-encryption mismatch", workers, i)
+			}
+		}
+	}
+
+	if _, _, err := pre.ReEncryptBatch(context.Background(), capsules, reKeys[1:], 0); !errors.Is(err, pre.ErrLengthMismatch) {
+		t.Errorf("want ErrLengthMismatch, got %v", err)
+	}
+
+	// cancelled batches report the context error for unscheduled items
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, errs, err := pre.ReEncryptBatch(ctx, capsules, reKeys, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("want context.Canceled, got %v", err)
+	}
+	for i := range errs {
+		if !errors.Is(errs[i], context.Canceled) {
+			t.Errorf("item %d: want context.Canceled, got %v", i, errs[i])
+		}
+	}
+}
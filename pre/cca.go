@@ -0,0 +1,130 @@
+package pre
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+const domainCapsuleProof = "proxyot/pre/capsule-proof"
+
+var (
+	// ErrInvalidCapsule occurs when the proof of a capsule does not verify,
+	// i.e. the capsule was not generated by Encrypt for the given public key,
+	// or when the capsule, the public key or the re-key are not points of the
+	// expected groups.
+	ErrInvalidCapsule = errors.New("invalid capsule")
+
+	// ErrCiphertextMismatch occurs when the ciphertext to be decrypted is not
+	// the one the capsule was generated with.
+	ErrCiphertextMismatch = errors.New("ciphertext does not match capsule")
+)
+
+// Capsule is the capsule of the CCA-secure variant. Besides A it carries the
+// digest of the ciphertext and a Schnorr proof of knowledge of r with
+// A = r * pkA, bound to the digest.
+type Capsule struct {
+	A      curve.Point
+	Digest [sha256.Size]byte // SHA256 of the ciphertext
+	C      *big.Int          // challenge
+	Z      *big.Int          // response, Z = w + C*r
+}
+
+// Verify verifies that capsule was generated for publicKey.
+func (capsule *Capsule) Verify(publicKey curve.Point) error {
+	if capsule == nil || !isG1(capsule.A) || !isG1(publicKey) || capsule.C == nil || capsule.Z == nil {
+		return ErrInvalidCapsule
+	}
+	// T = Z*pkA - C*A
+	T := newPoint().ScalarMult(publicKey, capsule.Z)
+	T.Add(T, newPoint().Neg(newPoint().ScalarMult(capsule.A, capsule.C)))
+	if capsuleChallenge(publicKey, capsule.A, T, capsule.Digest).Cmp(capsule.C) != 0 {
+		return ErrInvalidCapsule
+	}
+	return nil
+}
+
+// EncryptCCA encrypts input into output like Encrypt with NewEncryptClosure,
+// and returns a capsule bound to the written ciphertext.
+func EncryptCCA(publicKey curve.Point, input io.Reader, output io.Writer) (*Capsule, error) {
+	return defaultParams.EncryptCCA(publicKey, input, output)
+}
+
+// EncryptCCA is EncryptCCA with r, then the iv, then the proof randomness w
+// read from params.Rand.
+func (params *Params) EncryptCCA(publicKey curve.Point, input io.Reader, output io.Writer) (*Capsule, error) {
+	h := sha256.New()
+	A, r, err := params.encrypt(publicKey, params.NewEncryptClosure(input, io.MultiWriter(output, h)))
+	if err != nil {
+		return nil, err
+	}
+	capsule := &Capsule{A: A}
+	copy(capsule.Digest[:], h.Sum(nil))
+	// random w, T = w*pkA
+	w, err := curve.RandomFieldElement(params.random())
+	if err != nil {
+		return nil, err
+	}
+	T := newPoint().ScalarMult(publicKey, w)
+	capsule.C = capsuleChallenge(publicKey, A, T, capsule.Digest)
+	// Z = w + C*r
+	capsule.Z = new(big.Int).Mul(capsule.C, r)
+	capsule.Z.Add(capsule.Z, w).Mod(capsule.Z, curve.Order)
+	return capsule, nil
+}
+
+// ReEncryptCCA re-encrypts capsule like ReEncrypt, after verifying that it was
+// generated for publicKey.
+func ReEncryptCCA(capsule *Capsule, publicKey, rkAB curve.Point) (APrime curve.Point, err error) {
+	if err = capsule.Verify(publicKey); err != nil {
+		return nil, err
+	}
+	if !isG2(rkAB) {
+		return nil, ErrInvalidCapsule
+	}
+	return ReEncrypt(capsule.A, rkAB), nil
+}
+
+// DecryptCCAByReceiver verifies capsule and the ciphertext read from input,
+// then decrypts it into output like DecryptByReceiver.
+func DecryptCCAByReceiver(capsule *Capsule, publicKey, APrime curve.Point, b *big.Int, input io.Reader, output io.Writer) error {
+	ciphertext, err := readCiphertext(capsule, publicKey, input)
+	if err != nil {
+		return err
+	}
+	return DecryptByReceiver(APrime, b, NewDecryptClosure(bytes.NewReader(ciphertext), output))
+}
+
+// DecryptCCAByOwner verifies capsule and the ciphertext read from input,
+// then decrypts it into output like DecryptByOwner.
+func DecryptCCAByOwner(capsule *Capsule, a *big.Int, input io.Reader, output io.Writer) error {
+	publicKey := newPoint().ScalarBaseMult(a)
+	ciphertext, err := readCiphertext(capsule, publicKey, input)
+	if err != nil {
+		return err
+	}
+	return DecryptByOwner(capsule.A, a, NewDecryptClosure(bytes.NewReader(ciphertext), output))
+}
+
+func readCiphertext(capsule *Capsule, publicKey curve.Point, input io.Reader) ([]byte, error) {
+	if err := capsule.Verify(publicKey); err != nil {
+		return nil, err
+	}
+	ciphertext, err := ioutil.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	if sha256.Sum256(ciphertext) != capsule.Digest {
+		return nil, ErrCiphertextMismatch
+	}
+	return ciphertext, nil
+}
+
+func capsuleChallenge(publicKey, A, T curve.Point, digest [sha256.Size]byte) *big.Int {
+	return curve.DeriveChallenge(domainCapsuleProof, publicKey.Marshal(), A.Marshal(), T.Marshal(), digest[:])
+}
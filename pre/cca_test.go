@@ -0,0 +1,160 @@
+package pre_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/pre"
+)
+
+func TestCCA(t *testing.T) {
+	a, pkA, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := curve.RandomFieldElement(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := make([]byte, 100)
+	if _, err = mrand.Read(plaintext); err != nil {
+		t.Fatal(err)
+	}
+
+	// step 1: encrypt
+	cipherBuf := bytes.NewBuffer(nil)
+	capsule, err := pre.EncryptCCA(pkA, bytes.NewReader(plaintext), cipherBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext := cipherBuf.Bytes()
+	if err = capsule.Verify(pkA); err != nil {
+		t.Fatal(err)
+	}
+
+	// step 2: re-encrypt
+	APrime, err := pre.ReEncryptCCA(capsule, pkA, pre.GenerateReKey(a, b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// step 3: decrypt by receiver
+	deBuf := bytes.NewBuffer(nil)
+	if err = pre.DecryptCCAByReceiver(capsule, pkA, APrime, b, bytes.NewReader(ciphertext), deBuf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, deBuf.Bytes()) {
+		t.Errorf("decrypt by receiver error")
+	}
+
+	// step 4: decrypt by owner
+	deBuf.Reset()
+	if err = pre.DecryptCCAByOwner(capsule, a, bytes.NewReader(ciphertext), deBuf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, deBuf.Bytes()) {
+		t.Errorf("decrypt by owner error")
+	}
+}
+
+func TestCCAMalformed(t *testing.T) {
+	a, pkA, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := curve.RandomFieldElement(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rkAB := pre.GenerateReKey(a, b)
+	plaintext := make([]byte, 100)
+	if _, err = mrand.Read(plaintext); err != nil {
+		t.Fatal(err)
+	}
+	cipherBuf := bytes.NewBuffer(nil)
+	capsule, err := pre.EncryptCCA(pkA, bytes.NewReader(plaintext), cipherBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext := cipherBuf.Bytes()
+
+	// crafted point with a copied proof
+	crafted := *capsule
+	crafted.A = curve.NewPoint(curve.TypeG1).ScalarMult(capsule.A, big.NewInt(2))
+	if _, err = pre.ReEncryptCCA(&crafted, pkA, rkAB); !errors.Is(err, pre.ErrInvalidCapsule) {
+		t.Errorf("crafted A: want ErrInvalidCapsule, got %v", err)
+	}
+
+	// proof re-bound to another ciphertext
+	crafted = *capsule
+	crafted.Digest[0] ^= 0xff
+	if _, err = pre.ReEncryptCCA(&crafted, pkA, rkAB); !errors.Is(err, pre.ErrInvalidCapsule) {
+		t.Errorf("crafted digest: want ErrInvalidCapsule, got %v", err)
+	}
+
+	// tampered response
+	crafted = *capsule
+	crafted.Z = new(big.Int).Add(capsule.Z, big.NewInt(1))
+	if _, err = pre.ReEncryptCCA(&crafted, pkA, rkAB); !errors.Is(err, pre.ErrInvalidCapsule) {
+		t.Errorf("crafted response: want ErrInvalidCapsule, got %v", err)
+	}
+
+	// capsule of another owner
+	_, pkC, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = pre.ReEncryptCCA(capsule, pkC, rkAB); !errors.Is(err, pre.ErrInvalidCapsule) {
+		t.Errorf("other owner: want ErrInvalidCapsule, got %v", err)
+	}
+
+	// points of the wrong groups are rejected, not multiplied or paired
+	crafted = *capsule
+	crafted.A = rkAB
+	if _, err = pre.ReEncryptCCA(&crafted, pkA, rkAB); !errors.Is(err, pre.ErrInvalidCapsule) {
+		t.Errorf("G2 A: want ErrInvalidCapsule, got %v", err)
+	}
+	crafted.A = (*curve.G1)(nil)
+	if _, err = pre.ReEncryptCCA(&crafted, pkA, rkAB); !errors.Is(err, pre.ErrInvalidCapsule) {
+		t.Errorf("typed-nil A: want ErrInvalidCapsule, got %v", err)
+	}
+	if _, err = pre.ReEncryptCCA(capsule, rkAB, rkAB); !errors.Is(err, pre.ErrInvalidCapsule) {
+		t.Errorf("G2 public key: want ErrInvalidCapsule, got %v", err)
+	}
+	if _, err = pre.ReEncryptCCA(capsule, (*curve.G1)(nil), rkAB); !errors.Is(err, pre.ErrInvalidCapsule) {
+		t.Errorf("typed-nil public key: want ErrInvalidCapsule, got %v", err)
+	}
+	if _, err = pre.ReEncryptCCA(capsule, pkA, pkA); !errors.Is(err, pre.ErrInvalidCapsule) {
+		t.Errorf("G1 re-key: want ErrInvalidCapsule, got %v", err)
+	}
+	if _, err = pre.ReEncryptCCA(capsule, pkA, (*curve.G2)(nil)); !errors.Is(err, pre.ErrInvalidCapsule) {
+		t.Errorf("typed-nil re-key: want ErrInvalidCapsule, got %v", err)
+	}
+
+	// decryption rejects malformed capsules and tampered ciphertexts
+	APrime, err := pre.ReEncryptCCA(capsule, pkA, rkAB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crafted = *capsule
+	crafted.C = new(big.Int).Add(capsule.C, big.NewInt(1))
+	err = pre.DecryptCCAByReceiver(&crafted, pkA, APrime, b, bytes.NewReader(ciphertext), bytes.NewBuffer(nil))
+	if !errors.Is(err, pre.ErrInvalidCapsule) {
+		t.Errorf("crafted challenge: want ErrInvalidCapsule, got %v", err)
+	}
+	tampered := append([]byte(nil), ciphertext...)
+	tampered[len(tampered)-1] ^= 0xff
+	err = pre.DecryptCCAByReceiver(capsule, pkA, APrime, b, bytes.NewReader(tampered), bytes.NewBuffer(nil))
+	if !errors.Is(err, pre.ErrCiphertextMismatch) {
+		t.Errorf("tampered ciphertext: want ErrCiphertextMismatch, got %v", err)
+	}
+	err = pre.DecryptCCAByOwner(capsule, a, bytes.NewReader(tampered), bytes.NewBuffer(nil))
+	if !errors.Is(err, pre.ErrCiphertextMismatch) {
+		t.Errorf("tampered ciphertext: want ErrCiphertextMismatch, got %v", err)
+	}
+}
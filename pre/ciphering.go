@@ -5,7 +5,6 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/hmac"
-	"crypto/rand"
 	"crypto/sha256"
 	"crypto/sha512"
 	"errors"
@@ -31,6 +30,11 @@ var (
 )
 
 func NewEncryptClosure(input io.Reader, output io.Writer) EncryptClosure {
+	return defaultParams.NewEncryptClosure(input, output)
+}
+
+// NewEncryptClosure is NewEncryptClosure with the IV read from params.Rand.
+func (params *Params) NewEncryptClosure(input io.Reader, output io.Writer) EncryptClosure {
 	return func(key []byte) (err error) {
 		// generate derived key
 		derivedKey := sha512.Sum512(key)
@@ -51,7 +55,7 @@ func NewEncryptClosure(input io.Reader, output io.Writer) EncryptClosure {
 
 		// read entropy as iv
 		iv := text[:aes.BlockSize]
-		if _, err = io.ReadFull(rand.Reader, iv); err != nil {
+		if _, err = io.ReadFull(params.random(), iv); err != nil {
 			return err
 		}
 
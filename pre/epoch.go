@@ -0,0 +1,88 @@
+package pre
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// Epoch scoped delegation binds capsules and re-keys to an epoch label.
+//
+// The owner derives a secret scalar h(e) from its private key a and the
+// label e. The proxy stores capsules of the current epoch, A(e) = h(e) * A,
+// and an epoch re-key is rk(e) = (b / (a*h(e))) * G2, so that
+// e(A(e), rk(e)) = rb*Gt as in ReEncrypt. When the epoch rotates the owner
+// moves every stored capsule to e' by MoveEpochCapsule and hands the proxy the
+// new capsules, with the re-keys it still grants for e'.
+//
+// The ratio h(e')/h(e) never leaves the owner: with it the proxy would turn
+// rk(e) into rk(e') = (h(e)/h(e')) * rk(e) itself, and keep re-encrypting
+// after the epoch expired. Re-keys of an expired epoch yield garbage on the
+// capsules of later epochs, as the proxy only sees points A(e) and A(e') of
+// the same capsule, which do not reveal the ratio. The capsules of e the
+// proxy may have kept still re-encrypt under rk(e); the receiver could read
+// them during e anyway.
+
+const domainEpoch = "proxyot/pre/epoch"
+
+// ErrEpochMismatch occurs when a re-key is used on a capsule of another epoch.
+var ErrEpochMismatch = errors.New("re-key epoch does not match capsule epoch")
+
+// EpochCapsule is a capsule moved to an epoch. The empty epoch is the
+// unscoped capsule returned by Encrypt.
+type EpochCapsule struct {
+	Epoch string
+	A     curve.Point // A = h(e) * r*a*G1
+}
+
+// EpochReKey is a re-key that only works on capsules of its epoch.
+type EpochReKey struct {
+	Epoch string
+	K     curve.Point // K = (b / (a*h(e))) * G2
+}
+
+// GenerateEpochReKey generates the re-key from a to b for the given epoch.
+func GenerateEpochReKey(a, b *big.Int, epoch string) *EpochReKey {
+	ae := new(big.Int).Mul(a, epochScalar(a, epoch))
+	return &EpochReKey{
+		Epoch: epoch,
+		K:     GenerateReKey(ae.Mod(ae, curve.Order), b),
+	}
+}
+
+// MoveEpochCapsule moves capsule of owner a to epoch. The unscoped capsules
+// returned by Encrypt belong to the empty epoch.
+func MoveEpochCapsule(a *big.Int, capsule *EpochCapsule, epoch string) *EpochCapsule {
+	// h(epoch) / h(capsule.Epoch), kept by the owner
+	ratio := new(big.Int).ModInverse(epochScalar(a, capsule.Epoch), curve.Order)
+	ratio.Mul(ratio, epochScalar(a, epoch))
+	return &EpochCapsule{
+		Epoch: epoch,
+		A:     newPoint().ScalarMult(capsule.A, ratio.Mod(ratio, curve.Order)),
+	}
+}
+
+// ReEncryptEpoch re-encrypts capsule with rk after checking their epochs. The
+// check only catches mistakes; a re-key of another epoch yields garbage anyway.
+func ReEncryptEpoch(capsule *EpochCapsule, rk *EpochReKey) (APrime curve.Point, err error) {
+	if capsule.Epoch != rk.Epoch {
+		return nil, ErrEpochMismatch
+	}
+	return ReEncrypt(capsule.A, rk.K), nil
+}
+
+// DecryptEpochByOwner decrypts a capsule of any epoch by its owner a.
+func DecryptEpochByOwner(capsule *EpochCapsule, a *big.Int, decryptFunc DecryptClosure) error {
+	ae := new(big.Int).Mul(a, epochScalar(a, capsule.Epoch))
+	return DecryptByOwner(capsule.A, ae.Mod(ae, curve.Order), decryptFunc)
+}
+
+// epochScalar derives h(e) from the owner private key a. The empty epoch
+// maps to 1, so that capsules returned by Encrypt belong to it.
+func epochScalar(a *big.Int, epoch string) *big.Int {
+	if epoch == "" {
+		return big.NewInt(1)
+	}
+	return curve.DeriveChallenge(domainEpoch, a.Bytes(), []byte(epoch))
+}
@@ -0,0 +1,134 @@
+package pre_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/pre"
+)
+
+func TestEpochReKey(t *testing.T) {
+	a, _, b, A, plaintext, ciphertext := prepareCapsule(t)
+
+	// alice moves the stored capsule into the first epoch
+	capsule := pre.MoveEpochCapsule(a, &pre.EpochCapsule{A: A}, "2026-10")
+
+	// re-key of the current epoch works
+	rkOct := pre.GenerateEpochReKey(a, b, "2026-10")
+	APrime, err := pre.ReEncryptEpoch(capsule, rkOct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = decryptAPrime(APrime, b, ciphertext); err != nil {
+		t.Fatal(err)
+	}
+
+	// owner decrypts at any epoch
+	deBuf := bytes.NewBuffer(nil)
+	err = pre.DecryptEpochByOwner(capsule, a, pre.NewDecryptClosure(bytes.NewReader(ciphertext), deBuf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, deBuf.Bytes()) {
+		t.Errorf("decrypt by owner error")
+	}
+
+	// rotate the epoch
+	capsule = pre.MoveEpochCapsule(a, capsule, "2026-11")
+	if _, err = pre.ReEncryptEpoch(capsule, rkOct); !errors.Is(err, pre.ErrEpochMismatch) {
+		t.Errorf("want ErrEpochMismatch, got %v", err)
+	}
+
+	// re-key of the new epoch works
+	rkNov := pre.GenerateEpochReKey(a, b, "2026-11")
+	if APrime, err = pre.ReEncryptEpoch(capsule, rkNov); err != nil {
+		t.Fatal(err)
+	}
+	deBuf.Reset()
+	err = pre.DecryptByReceiver(APrime, b, pre.NewDecryptClosure(bytes.NewReader(ciphertext), deBuf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, deBuf.Bytes()) {
+		t.Errorf("decrypt by receiver error")
+	}
+
+	// epoch scalars depend on the owner private key
+	other, err := curve.RandomFieldElement(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := pre.MoveEpochCapsule(other, capsule, "2026-12")
+	if err = decryptAPrime(pre.ReEncrypt(forged.A, pre.GenerateEpochReKey(a, b, "2026-12").K), b, ciphertext); err == nil {
+		t.Error("decrypted with capsule moved by another owner key")
+	}
+}
+
+func TestEpochExpiredReKey(t *testing.T) {
+	a, pkA, b, A, _, ciphertext := prepareCapsule(t)
+
+	// the proxy holds a capsule and the re-key of october
+	rkOct := pre.GenerateEpochReKey(a, b, "2026-10")
+	octCapsule := pre.MoveEpochCapsule(a, &pre.EpochCapsule{A: A}, "2026-10")
+
+	// in november it receives the moved capsule and a new one, but no re-key
+	novCapsule := pre.MoveEpochCapsule(a, octCapsule, "2026-11")
+	newCiphertext := bytes.NewBuffer(nil)
+	newA, err := pre.Encrypt(pkA, pre.NewEncryptClosure(bytes.NewReader([]byte("november data")), newCiphertext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newCapsule := pre.MoveEpochCapsule(a, &pre.EpochCapsule{A: newA}, "2026-11")
+
+	// the expired re-key yields garbage on every capsule of november
+	if err = decryptAPrime(pre.ReEncrypt(novCapsule.A, rkOct.K), b, ciphertext); err == nil {
+		t.Error("decrypted moved capsule with re-key of an expired epoch")
+	}
+	if err = decryptAPrime(pre.ReEncrypt(newCapsule.A, rkOct.K), b, newCiphertext.Bytes()); err == nil {
+		t.Error("decrypted new capsule with re-key of an expired epoch")
+	}
+}
+
+func TestRevocationList(t *testing.T) {
+	a, _, b, A, _, ciphertext := prepareCapsule(t)
+	c, err := curve.RandomFieldElement(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rkAB, rkAC := pre.GenerateReKey(a, b), pre.GenerateReKey(a, c)
+
+	rl := pre.NewRevocationList()
+	APrime, err := rl.ReEncrypt(A, rkAB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = decryptAPrime(APrime, b, ciphertext); err != nil {
+		t.Fatal(err)
+	}
+
+	// revoke bob, carol is not affected
+	rl.Revoke(pre.NewReKeyID(rkAB))
+	if !rl.IsRevoked(rkAB) || rl.IsRevoked(rkAC) {
+		t.Fatal("wrong revocation state")
+	}
+	if _, err = rl.ReEncrypt(A, rkAB); !errors.Is(err, pre.ErrReKeyRevoked) {
+		t.Errorf("want ErrReKeyRevoked, got %v", err)
+	}
+	if _, err = rl.ReEncrypt(A, rkAC); err != nil {
+		t.Errorf("re-encrypt with carol re-key: %v", err)
+	}
+
+	// epoch re-keys
+	capsule := pre.MoveEpochCapsule(a, &pre.EpochCapsule{A: A}, "2026-10")
+	rk := pre.GenerateEpochReKey(a, c, "2026-10")
+	if _, err = rl.ReEncryptEpoch(capsule, rk); err != nil {
+		t.Fatal(err)
+	}
+	rl.Revoke(pre.NewReKeyID(rk.K))
+	if _, err = rl.ReEncryptEpoch(capsule, rk); !errors.Is(err, pre.ErrReKeyRevoked) {
+		t.Errorf("want ErrReKeyRevoked, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package pre_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/pre"
+)
+
+func prepareCapsule(t *testing.T) (a *big.Int, pkA curve.Point, b *big.Int, A curve.Point, plaintext, ciphertext []byte) {
+	var err error
+	if a, pkA, err = curve.NewRandomPoint(curve.TypeG1, rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	if b, err = curve.RandomFieldElement(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	plaintext = make([]byte, 100)
+	if _, err = mrand.Read(plaintext); err != nil {
+		t.Fatal(err)
+	}
+	cipherBuf := bytes.NewBuffer(nil)
+	if A, err = pre.Encrypt(pkA, pre.NewEncryptClosure(bytes.NewReader(plaintext), cipherBuf)); err != nil {
+		t.Fatal(err)
+	}
+	return a, pkA, b, A, plaintext, cipherBuf.Bytes()
+}
+
+func decryptAPrime(APrime curve.Point, b *big.Int, ciphertext []byte) error {
+	return pre.DecryptByReceiver(APrime, b, pre.NewDecryptClosure(bytes.NewReader(ciphertext), bytes.NewBuffer(nil)))
+}
@@ -0,0 +1,149 @@
+package pre
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// Multi-recipient encryption encrypts the payload once under a random data
+// key, and wraps the data key in a PRE capsule for every recipient. Every
+// recipient capsule is an ordinary capsule of its public key, so it can also
+// be delegated with GenerateReKey and ReEncrypt.
+
+const (
+	dataKeySize = 32
+
+	// recipientMinSize is the size of a recipient record with an empty wrapped key.
+	recipientMinSize = 64 + 64 + 4
+)
+
+var (
+	// ErrNotRecipient occurs when the private key is not one of the envelope recipients.
+	ErrNotRecipient = errors.New("not a recipient of the envelope")
+
+	errInvalidEnvelope = errors.New("invalid envelope encoding")
+)
+
+// Envelope holds the wrapped data keys of a multi-recipient payload.
+type Envelope struct {
+	Recipients []*Recipient
+}
+
+// Recipient is the data key wrapped for a single recipient.
+type Recipient struct {
+	PublicKey  curve.Point // G1 public key of the recipient
+	A          curve.Point // capsule of the wrapped key, A = r * PublicKey
+	WrappedKey []byte      // data key encrypted with NewEncryptClosure under the capsule key
+}
+
+// EncryptMulti generates a data key, encrypts the payload with it by
+// encryptFunc, and wraps the data key for every public key.
+func EncryptMulti(publicKeys []curve.Point, encryptFunc EncryptClosure) (*Envelope, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	if err := encryptFunc(dataKey); err != nil {
+		return nil, err
+	}
+	env := &Envelope{Recipients: make([]*Recipient, len(publicKeys))}
+	for i, publicKey := range publicKeys {
+		wrapped := bytes.NewBuffer(nil)
+		A, err := Encrypt(publicKey, NewEncryptClosure(bytes.NewReader(dataKey), wrapped))
+		if err != nil {
+			return nil, err
+		}
+		env.Recipients[i] = &Recipient{
+			PublicKey:  publicKey,
+			A:          A,
+			WrappedKey: wrapped.Bytes(),
+		}
+	}
+	return env, nil
+}
+
+// Recipient returns the entry of publicKey.
+func (env *Envelope) Recipient(publicKey curve.Point) (*Recipient, error) {
+	target := publicKey.Marshal()
+	for _, recipient := range env.Recipients {
+		if bytes.Equal(recipient.PublicKey.Marshal(), target) {
+			return recipient, nil
+		}
+	}
+	return nil, ErrNotRecipient
+}
+
+// DecryptByRecipient unwraps the data key with private key sk of a recipient,
+// then decrypts the payload by decryptFunc.
+func (env *Envelope) DecryptByRecipient(sk *big.Int, decryptFunc DecryptClosure) error {
+	recipient, err := env.Recipient(newPoint().ScalarBaseMult(sk))
+	if err != nil {
+		return err
+	}
+	dataKey := bytes.NewBuffer(nil)
+	err = DecryptByOwner(recipient.A, sk, NewDecryptClosure(bytes.NewReader(recipient.WrappedKey), dataKey))
+	if err != nil {
+		return err
+	}
+	return decryptFunc(dataKey.Bytes())
+}
+
+// Marshal encodes the envelope as:
+// count(4) || [public_key(64) || A(64) || len(4) || wrapped_key] * count
+func (env *Envelope) Marshal() []byte {
+	var buf bytes.Buffer
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(env.Recipients)))
+	buf.Write(size[:])
+	for _, recipient := range env.Recipients {
+		buf.Write(recipient.PublicKey.Marshal())
+		buf.Write(recipient.A.Marshal())
+		binary.BigEndian.PutUint32(size[:], uint32(len(recipient.WrappedKey)))
+		buf.Write(size[:])
+		buf.Write(recipient.WrappedKey)
+	}
+	return buf.Bytes()
+}
+
+// Unmarshal decodes an envelope encoded by Marshal.
+func (env *Envelope) Unmarshal(m []byte) (err error) {
+	if len(m) < 4 {
+		return errInvalidEnvelope
+	}
+	count := binary.BigEndian.Uint32(m)
+	m = m[4:]
+	if uint64(count) > uint64(len(m)/recipientMinSize) {
+		return errInvalidEnvelope
+	}
+	recipients := make([]*Recipient, 0, count)
+	for i := uint32(0); i < count; i++ {
+		recipient := &Recipient{PublicKey: newPoint(), A: newPoint()}
+		if m, err = recipient.PublicKey.Unmarshal(m); err != nil {
+			return err
+		}
+		if m, err = recipient.A.Unmarshal(m); err != nil {
+			return err
+		}
+		if len(m) < 4 {
+			return errInvalidEnvelope
+		}
+		size := binary.BigEndian.Uint32(m)
+		if m = m[4:]; uint32(len(m)) < size {
+			return errInvalidEnvelope
+		}
+		recipient.WrappedKey = append([]byte(nil), m[:size]...)
+		m = m[size:]
+		recipients = append(recipients, recipient)
+	}
+	if len(m) != 0 {
+		return errInvalidEnvelope
+	}
+	env.Recipients = recipients
+	return nil
+}
@@ -0,0 +1,105 @@
+package pre_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/pre"
+)
+
+func TestMultiRecipient(t *testing.T) {
+	var count = 50
+
+	sks := make([]*big.Int, count)
+	pks := make([]curve.Point, count)
+	for i := range sks {
+		var err error
+		if sks[i], pks[i], err = curve.NewRandomPoint(curve.TypeG1, rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	plaintext := make([]byte, 1000)
+	if _, err := mrand.Read(plaintext); err != nil {
+		t.Fatal(err)
+	}
+
+	// encrypt once for all recipients
+	cipherBuf := bytes.NewBuffer(nil)
+	env, err := pre.EncryptMulti(pks, pre.NewEncryptClosure(bytes.NewReader(plaintext), cipherBuf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext := cipherBuf.Bytes()
+
+	// the recipient list travels with the envelope
+	decoded := &pre.Envelope{}
+	if err = decoded.Unmarshal(env.Marshal()); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Recipients) != count {
+		t.Fatalf("want %d recipients, got %d", count, len(decoded.Recipients))
+	}
+	for i := range pks {
+		if _, err = decoded.Recipient(pks[i]); err != nil {
+			t.Fatalf("recipient %d: %v", i, err)
+		}
+	}
+
+	// every recipient decrypts with its own key
+	for i := range sks {
+		deBuf := bytes.NewBuffer(nil)
+		err = decoded.DecryptByRecipient(sks[i], pre.NewDecryptClosure(bytes.NewReader(ciphertext), deBuf))
+		if err != nil {
+			t.Fatalf("recipient %d: %v", i, err)
+		}
+		if !bytes.Equal(plaintext, deBuf.Bytes()) {
+			t.Errorf("recipient %d: decrypt error", i)
+		}
+	}
+
+	// outsiders are rejected
+	outsider, err := curve.RandomFieldElement(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = decoded.DecryptByRecipient(outsider, pre.NewDecryptClosure(bytes.NewReader(ciphertext), bytes.NewBuffer(nil)))
+	if !errors.Is(err, pre.ErrNotRecipient) {
+		t.Errorf("want ErrNotRecipient, got %v", err)
+	}
+
+	// a recipient capsule can still be delegated through a proxy
+	b, err := curve.RandomFieldElement(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := decoded.Recipients[7]
+	APrime := pre.ReEncrypt(recipient.A, pre.GenerateReKey(sks[7], b))
+	dataKey := bytes.NewBuffer(nil)
+	err = pre.DecryptByReceiver(APrime, b, pre.NewDecryptClosure(bytes.NewReader(recipient.WrappedKey), dataKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deBuf := bytes.NewBuffer(nil)
+	if err = pre.NewDecryptClosure(bytes.NewReader(ciphertext), deBuf)(dataKey.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, deBuf.Bytes()) {
+		t.Errorf("decrypt by delegate error")
+	}
+
+	// truncated envelopes are rejected
+	data := env.Marshal()
+	if err = new(pre.Envelope).Unmarshal(data[:len(data)-1]); err == nil {
+		t.Error("decoded truncated envelope")
+	}
+	// so are counts larger than the input, before allocating them
+	if err = new(pre.Envelope).Unmarshal([]byte{0xff, 0xff, 0xff, 0xff}); err == nil {
+		t.Error("decoded envelope of oversized count")
+	}
+}
@@ -0,0 +1,116 @@
+package pre
+
+import (
+	"io"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// The multi-hop scheme keeps capsules in G1 so that a re-encrypted capsule
+// can be re-encrypted again. A capsule held by private key d is A = r*d*G1,
+// a hop re-key from d to e is the scalar e/d, and the symmetric key is the
+// same B = rGt as in the single-hop scheme.
+//
+// Hop re-keys are bidirectional: a proxy colluding with either side of a hop
+// learns the private key of the other side.
+//
+// As in BBS98, a scalar re-key cannot be derived from a public key, so a hop
+// re-key is either generated by a party holding both private keys, by
+// GenerateHopReKey, or interactively between the proxy and both sides of the
+// hop, by BlindHopReKey, ContributeHopReKey and UnblindHopReKey, where no
+// party reveals its private key to another.
+
+// HopPublicKey is a public key of the multi-hop scheme.
+type HopPublicKey struct {
+	P curve.Point // P = d * G1
+}
+
+// HopPrivateKey is a private key of the multi-hop scheme.
+type HopPrivateKey struct {
+	HopPublicKey
+	D *big.Int
+}
+
+// HopReKey turns capsules held by one private key into capsules held by another.
+type HopReKey struct {
+	K *big.Int // K = e / d
+}
+
+// HopCapsule is a capsule of the multi-hop scheme.
+type HopCapsule struct {
+	A   curve.Point // A = r * d * G1, where d is the private key of the current holder
+	Hop uint32      // number of re-encryptions applied so far
+}
+
+// GenerateHopKey generates a multi-hop key pair from r.
+func GenerateHopKey(r io.Reader) (*HopPrivateKey, error) {
+	d, P, err := curve.NewRandomPoint(curve.TypeG1, r)
+	if err != nil {
+		return nil, err
+	}
+	return &HopPrivateKey{HopPublicKey: HopPublicKey{P: P}, D: d}, nil
+}
+
+// EncryptHop encrypts for the holder of pk. The returned capsule is at hop 0,
+// and its A equals the capsule returned by Encrypt.
+func EncryptHop(pk *HopPublicKey, encryptFunc EncryptClosure) (*HopCapsule, error) {
+	A, err := Encrypt(pk.P, encryptFunc)
+	if err != nil {
+		return nil, err
+	}
+	return &HopCapsule{A: A}, nil
+}
+
+// GenerateHopReKey generates the re-key from the holder of from to the holder
+// of to. It needs both private keys; without them, the re-key is generated
+// interactively, see BlindHopReKey.
+func GenerateHopReKey(from, to *HopPrivateKey) *HopReKey {
+	// rk = e / d
+	id := new(big.Int).ModInverse(from.D, curve.Order) // d^-1 mod Order
+	k := new(big.Int).Mul(to.D, id)
+	return &HopReKey{K: k.Mod(k, curve.Order)}
+}
+
+// BlindHopReKey is the delegator step of the interactive re-key generation.
+// The proxy draws a random blind x with curve.RandomFieldElement and sends it
+// to the holder of from, who returns x/d to the delegatee. Then the delegatee
+// returns ContributeHopReKey of it to the proxy, which opens the re-key by
+// UnblindHopReKey with x.
+func BlindHopReKey(from *HopPrivateKey, x *big.Int) *big.Int {
+	// x / d
+	id := new(big.Int).ModInverse(from.D, curve.Order) // d^-1 mod Order
+	k := new(big.Int).Mul(x, id)
+	return k.Mod(k, curve.Order)
+}
+
+// ContributeHopReKey is the delegatee step of the interactive re-key
+// generation: it multiplies the blinded value of the delegator by e.
+func ContributeHopReKey(to *HopPrivateKey, blinded *big.Int) *big.Int {
+	// e * x / d
+	k := new(big.Int).Mul(blinded, to.D)
+	return k.Mod(k, curve.Order)
+}
+
+// UnblindHopReKey is the proxy step of the interactive re-key generation: it
+// removes the blind x from the contribution of the delegatee.
+func UnblindHopReKey(x, contributed *big.Int) *HopReKey {
+	// rk = (e * x / d) / x = e / d
+	ix := new(big.Int).ModInverse(x, curve.Order) // x^-1 mod Order
+	k := new(big.Int).Mul(contributed, ix)
+	return &HopReKey{K: k.Mod(k, curve.Order)}
+}
+
+// ReEncryptHop re-encrypts capsule with rk. The capsule itself is not modified.
+func ReEncryptHop(capsule *HopCapsule, rk *HopReKey) *HopCapsule {
+	// A' = rk * A = (e/d) * r*d*G1 = r*e*G1
+	return &HopCapsule{
+		A:   newPoint().ScalarMult(capsule.A, rk.K),
+		Hop: capsule.Hop + 1,
+	}
+}
+
+// DecryptHop decrypts a capsule at any hop level by its current holder.
+func DecryptHop(capsule *HopCapsule, priv *HopPrivateKey, decryptFunc DecryptClosure) error {
+	return DecryptByOwner(capsule.A, priv.D, decryptFunc)
+}
@@ -0,0 +1,140 @@
+package pre_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/pre"
+)
+
+func TestMultiHop(t *testing.T) {
+	var parties = 5 // alice, bob, carol, dave, eve: 4 hops
+
+	keys := make([]*pre.HopPrivateKey, parties)
+	for i := range keys {
+		var err error
+		if keys[i], err = pre.GenerateHopKey(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	plaintext := make([]byte, 100)
+	if _, err := mrand.Read(plaintext); err != nil {
+		t.Fatal(err)
+	}
+
+	// encrypt for alice
+	cipherBuf := bytes.NewBuffer(nil)
+	capsule, err := pre.EncryptHop(&keys[0].HopPublicKey, pre.NewEncryptClosure(bytes.NewReader(plaintext), cipherBuf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext := cipherBuf.Bytes()
+
+	// walk the delegation chain, every holder decrypts at its own hop
+	for i := 0; i < parties; i++ {
+		if i > 0 {
+			rk := pre.GenerateHopReKey(keys[i-1], keys[i])
+			capsule = pre.ReEncryptHop(capsule, rk)
+		}
+		if capsule.Hop != uint32(i) {
+			t.Fatalf("hop %d: capsule hop is %d", i, capsule.Hop)
+		}
+
+		deBuf := bytes.NewBuffer(nil)
+		err = pre.DecryptHop(capsule, keys[i], pre.NewDecryptClosure(bytes.NewReader(ciphertext), deBuf))
+		if err != nil {
+			t.Fatalf("hop %d: %v", i, err)
+		}
+		if !bytes.Equal(plaintext, deBuf.Bytes()) {
+			t.Errorf("hop %d: decrypt error", i)
+		}
+
+		// nobody else in the chain can open the capsule at this hop
+		for j := 0; j < parties; j++ {
+			if j == i {
+				continue
+			}
+			err = pre.DecryptHop(capsule, keys[j], pre.NewDecryptClosure(bytes.NewReader(ciphertext), bytes.NewBuffer(nil)))
+			if err == nil {
+				t.Errorf("hop %d: party %d decrypted capsule of party %d", i, j, i)
+			}
+		}
+	}
+}
+
+func TestMultiHopFromEncrypt(t *testing.T) {
+	alice, err := pre.GenerateHopKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := pre.GenerateHopKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := make([]byte, 100)
+	if _, err = mrand.Read(plaintext); err != nil {
+		t.Fatal(err)
+	}
+
+	// a capsule of the single-hop scheme is a hop 0 capsule
+	cipherBuf := bytes.NewBuffer(nil)
+	A, err := pre.Encrypt(alice.P, pre.NewEncryptClosure(bytes.NewReader(plaintext), cipherBuf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	capsule := pre.ReEncryptHop(&pre.HopCapsule{A: A}, pre.GenerateHopReKey(alice, bob))
+	deBuf := bytes.NewBuffer(nil)
+	err = pre.DecryptHop(capsule, bob, pre.NewDecryptClosure(bytes.NewReader(cipherBuf.Bytes()), deBuf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, deBuf.Bytes()) {
+		t.Errorf("decrypt by bob error")
+	}
+}
+
+func TestMultiHopInteractiveReKey(t *testing.T) {
+	bob, err := pre.GenerateHopKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	carol, err := pre.GenerateHopKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the proxy blinds, bob and carol each apply their own private key only
+	x, err := curve.RandomFieldElement(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blinded := pre.BlindHopReKey(bob, x)
+	rk := pre.UnblindHopReKey(x, pre.ContributeHopReKey(carol, blinded))
+	if rk.K.Cmp(pre.GenerateHopReKey(bob, carol).K) != 0 {
+		t.Fatal("interactive re-key not equal to re-key")
+	}
+
+	plaintext := make([]byte, 100)
+	if _, err = mrand.Read(plaintext); err != nil {
+		t.Fatal(err)
+	}
+	cipherBuf := bytes.NewBuffer(nil)
+	capsule, err := pre.EncryptHop(&bob.HopPublicKey, pre.NewEncryptClosure(bytes.NewReader(plaintext), cipherBuf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deBuf := bytes.NewBuffer(nil)
+	err = pre.DecryptHop(pre.ReEncryptHop(capsule, rk), carol, pre.NewDecryptClosure(bytes.NewReader(cipherBuf.Bytes()), deBuf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, deBuf.Bytes()) {
+		t.Errorf("decrypt by carol error")
+	}
+}
@@ -2,13 +2,33 @@ package pre
 
 import (
 	"crypto/rand"
+	"errors"
+	"io"
 	"math/big"
 
 	"github.com/clarenous/proxyot/curve"
 )
 
+// Params is the PRE handler contains some parameters.
+type Params struct {
+	// Rand is the source of randomness, crypto/rand.Reader is used if nil.
+	// Supplying a deterministic reader reproduces the same capsules and
+	// ciphertexts.
+	Rand io.Reader
+}
+
 func Encrypt(publicKey curve.Point, encryptFunc EncryptClosure) (A curve.Point, err error) {
-	r, err := curve.RandomFieldElement(rand.Reader)
+	return defaultParams.Encrypt(publicKey, encryptFunc)
+}
+
+// Encrypt is Encrypt with the randomness read from params.Rand.
+func (params *Params) Encrypt(publicKey curve.Point, encryptFunc EncryptClosure) (A curve.Point, err error) {
+	A, _, err = params.encrypt(publicKey, encryptFunc)
+	return
+}
+
+func (params *Params) encrypt(publicKey curve.Point, encryptFunc EncryptClosure) (A curve.Point, r *big.Int, err error) {
+	r, err = curve.RandomFieldElement(params.random())
 	if err != nil {
 		return
 	}
@@ -27,6 +47,25 @@ func GenerateReKey(a, b *big.Int) (rkAB curve.Point) {
 	return
 }
 
+var errInvalidReceiverKey = errors.New("receiver public key is not a G2 point")
+
+// ReceiverPublicKey returns b * G2, the public key a receiver publishes for
+// owners to delegate to it by GenerateReKeyFromPublicKey.
+func ReceiverPublicKey(b *big.Int) curve.Point {
+	return newTwistPoint().ScalarBaseMult(b)
+}
+
+// GenerateReKeyFromPublicKey generates the same re-key as GenerateReKey, using
+// the G2 public key pkB = b * G2 of the receiver instead of its secret key.
+func GenerateReKeyFromPublicKey(a *big.Int, pkB curve.Point) (rkAB curve.Point, err error) {
+	if _, ok := pkB.(*curve.G2); !ok {
+		return nil, errInvalidReceiverKey
+	}
+	// rkAB = a^-1 * pkB = (b/a) * G
+	ia := new(big.Int).ModInverse(a, curve.Order)
+	return newTwistPoint().ScalarMult(pkB, ia), nil
+}
+
 func ReEncrypt(A, rkAB curve.Point) (APrime curve.Point) {
 	APrime = curve.Pair(A.(*curve.G1), rkAB.(*curve.G2))
 	return
@@ -47,6 +86,15 @@ func DecryptByOwner(A curve.Point, a *big.Int, decryptFunc DecryptClosure) (err
 	return
 }
 
+func (params *Params) random() io.Reader {
+	if params.Rand == nil {
+		return rand.Reader
+	}
+	return params.Rand
+}
+
+var defaultParams = &Params{}
+
 var oneTwistPoint = newTwistPoint().ScalarBaseMult(big.NewInt(1))
 
 func newPoint() curve.Point {
@@ -60,3 +108,18 @@ func newTwistPoint() curve.Point {
 func newPairedPoint() curve.Point {
 	return curve.NewPoint(curve.TypeGT)
 }
+
+func isG1(p curve.Point) bool {
+	g, ok := p.(*curve.G1)
+	return ok && g != nil
+}
+
+func isG2(p curve.Point) bool {
+	g, ok := p.(*curve.G2)
+	return ok && g != nil
+}
+
+func isGT(p curve.Point) bool {
+	g, ok := p.(*curve.GT)
+	return ok && g != nil
+}
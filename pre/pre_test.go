@@ -64,6 +64,54 @@ func TestPre(t *testing.T) {
 	}
 }
 
+func TestGenerateReKeyFromPublicKey(t *testing.T) {
+	a, publicKeyA, err := curve.NewRandomPoint(curve.TypeG1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// receiver publishes b * G2 and keeps b
+	b, err := curve.RandomFieldElement(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKeyB := pre.ReceiverPublicKey(b)
+
+	plaintext := make([]byte, 100)
+	if _, err = mrand.Read(plaintext); err != nil {
+		t.Fatal(err)
+	}
+	cipherBuf := bytes.NewBuffer(nil)
+	A, err := pre.Encrypt(publicKeyA, pre.NewEncryptClosure(bytes.NewReader(plaintext), cipherBuf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// owner delegates with its own secret key and the public key only
+	rkAB, err := pre.GenerateReKeyFromPublicKey(a, publicKeyB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rkAB.Marshal(), pre.GenerateReKey(a, b).Marshal()) {
+		t.Errorf("re-key differs from GenerateReKey")
+	}
+
+	APrime := pre.ReEncrypt(A, rkAB)
+	deBuf := bytes.NewBuffer(nil)
+	err = pre.DecryptByReceiver(APrime, b, pre.NewDecryptClosure(bytes.NewReader(cipherBuf.Bytes()), deBuf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, deBuf.Bytes()) {
+		t.Errorf("decrypt by receiver error")
+	}
+
+	// G1 public keys are rejected
+	if _, err = pre.GenerateReKeyFromPublicKey(a, publicKeyA); err == nil {
+		t.Errorf("accepted a G1 public key")
+	}
+}
+
 func TestGenerateReKeyTime(t *testing.T) {
 	var round = 10_000
 	testGenerateReKeyTime(round, true)
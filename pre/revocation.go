@@ -0,0 +1,68 @@
+package pre
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// ErrReKeyRevoked occurs when a proxy is asked to re-encrypt with a revoked re-key.
+var ErrReKeyRevoked = errors.New("re-key revoked")
+
+// ReKeyID identifies a re-key without revealing it.
+type ReKeyID [sha256.Size]byte
+
+// NewReKeyID returns the identifier of re-key rk.
+func NewReKeyID(rk curve.Point) ReKeyID {
+	return sha256.Sum256(rk.Marshal())
+}
+
+func (id ReKeyID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// RevocationList is the proxy-side list of revoked re-keys.
+// It is safe for concurrent use.
+type RevocationList struct {
+	l       sync.RWMutex
+	revoked map[ReKeyID]struct{}
+}
+
+// NewRevocationList creates an empty revocation list.
+func NewRevocationList() *RevocationList {
+	return &RevocationList{revoked: make(map[ReKeyID]struct{})}
+}
+
+// Revoke revokes the re-key with the given identifier.
+func (rl *RevocationList) Revoke(id ReKeyID) {
+	rl.l.Lock()
+	defer rl.l.Unlock()
+	rl.revoked[id] = struct{}{}
+}
+
+// IsRevoked reports whether re-key rk has been revoked.
+func (rl *RevocationList) IsRevoked(rk curve.Point) bool {
+	rl.l.RLock()
+	defer rl.l.RUnlock()
+	_, ok := rl.revoked[NewReKeyID(rk)]
+	return ok
+}
+
+// ReEncrypt re-encrypts A like ReEncrypt, unless rkAB has been revoked.
+func (rl *RevocationList) ReEncrypt(A, rkAB curve.Point) (APrime curve.Point, err error) {
+	if rl.IsRevoked(rkAB) {
+		return nil, ErrReKeyRevoked
+	}
+	return ReEncrypt(A, rkAB), nil
+}
+
+// ReEncryptEpoch re-encrypts capsule like ReEncryptEpoch, unless rk has been revoked.
+func (rl *RevocationList) ReEncryptEpoch(capsule *EpochCapsule, rk *EpochReKey) (APrime curve.Point, err error) {
+	if rl.IsRevoked(rk.K) {
+		return nil, ErrReKeyRevoked
+	}
+	return ReEncryptEpoch(capsule, rk)
+}
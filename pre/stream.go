@@ -0,0 +1,36 @@
+package pre
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// StreamReKeys generates the re-key GenerateReKey(a, b) of every b received
+// from bs, in the same order. The returned channel is closed once bs is
+// closed and drained, or early if ctx is done.
+func StreamReKeys(ctx context.Context, a *big.Int, bs <-chan *big.Int) <-chan curve.Point {
+	reKeys := make(chan curve.Point)
+	go func() {
+		defer close(reKeys)
+		for {
+			var b *big.Int
+			var ok bool
+			select {
+			case b, ok = <-bs:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case reKeys <- GenerateReKey(a, b):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return reKeys
+}
@@ -0,0 +1,42 @@
+package pre_test
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/pre"
+)
+
+func TestStreamReKeys(t *testing.T) {
+	a, err := curve.RandomFieldElement(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs := make([]*big.Int, 10)
+	for i := range bs {
+		if bs[i], err = curve.RandomFieldElement(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	in := make(chan *big.Int)
+	go func() {
+		defer close(in)
+		for _, b := range bs {
+			in <- b
+		}
+	}()
+	var i int
+	for reKey := range pre.StreamReKeys(context.Background(), a, in) {
+		if reKey.String() != pre.GenerateReKey(a, bs[i]).String() {
+			t.Errorf("re-key %d mismatch", i)
+		}
+		i++
+	}
+	if i != len(bs) {
+		t.Errorf("want %d re-keys, got %d", len(bs), i)
+	}
+}
@@ -0,0 +1,159 @@
+package pre
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+// Threshold re-encryption splits the re-key rk = (b/a)*G2 among n proxies
+// with Shamir secret sharing over curve.Order, so that any k of the
+// re-encrypted fragments combine into the APrime of ReEncrypt.
+
+var (
+	// ErrInvalidThreshold occurs when the threshold is not in [1, n].
+	ErrInvalidThreshold = errors.New("invalid threshold")
+
+	// ErrNotEnoughFragments occurs when less than threshold capsule fragments
+	// are given to CombineFragments.
+	ErrNotEnoughFragments = errors.New("not enough capsule fragments")
+
+	// ErrDuplicateFragment occurs when capsule fragments share IDs, so that less
+	// than threshold distinct ones are given to CombineFragments.
+	ErrDuplicateFragment = errors.New("duplicate capsule fragment")
+
+	// ErrInvalidFragment occurs when a capsule fragment has no ID, an ID not in
+	// [1, curve.Order), or an APrime that is not a GT point.
+	ErrInvalidFragment = errors.New("invalid capsule fragment")
+)
+
+// KeyFragment is the share of a re-key held by a single proxy.
+type KeyFragment struct {
+	ID *big.Int    // x coordinate of the share, non-zero
+	K  curve.Point // K = f(ID) * G2, where f(0) = b/a
+}
+
+// CapsuleFragment is the share of a re-encrypted capsule produced by a single proxy.
+type CapsuleFragment struct {
+	ID     *big.Int
+	APrime curve.Point // APrime = e(A, K)
+}
+
+// GenerateKeyFragments splits the re-key from a to b into n fragments,
+// any threshold of which are enough to re-encrypt.
+func GenerateKeyFragments(a, b *big.Int, threshold, n int) (kFrags []*KeyFragment, err error) {
+	return defaultParams.GenerateKeyFragments(a, b, threshold, n)
+}
+
+// GenerateKeyFragments is GenerateKeyFragments with the coefficients, then the
+// IDs, read from params.Rand.
+func (params *Params) GenerateKeyFragments(a, b *big.Int, threshold, n int) (kFrags []*KeyFragment, err error) {
+	if threshold < 1 || threshold > n {
+		return nil, ErrInvalidThreshold
+	}
+	// f(x) = b/a + c1*x + ... + c(k-1)*x^(k-1)
+	coeffs := make([]*big.Int, threshold)
+	ia := new(big.Int).ModInverse(a, curve.Order) // a^-1 mod Order
+	coeffs[0] = ia.Mul(ia, b).Mod(ia, curve.Order)
+	for i := 1; i < threshold; i++ {
+		if coeffs[i], err = curve.RandomFieldElement(params.random()); err != nil {
+			return nil, err
+		}
+	}
+	// random distinct ids, so that fragments do not reveal the proxy ordinal
+	ids := make(map[string]bool, n)
+	kFrags = make([]*KeyFragment, 0, n)
+	for len(kFrags) < n {
+		var id *big.Int
+		if id, err = curve.RandomFieldElement(params.random()); err != nil {
+			return nil, err
+		}
+		if ids[id.String()] {
+			continue
+		}
+		ids[id.String()] = true
+		kFrags = append(kFrags, &KeyFragment{
+			ID: id,
+			K:  newTwistPoint().ScalarBaseMult(evaluatePolynomial(coeffs, id)),
+		})
+	}
+	return kFrags, nil
+}
+
+// ReEncryptFragment re-encrypts capsule A with a single key fragment.
+func ReEncryptFragment(A curve.Point, kFrag *KeyFragment) *CapsuleFragment {
+	return &CapsuleFragment{
+		ID:     kFrag.ID,
+		APrime: ReEncrypt(A, kFrag.K),
+	}
+}
+
+// CombineFragments combines the first threshold capsule fragments of distinct
+// IDs into the APrime that DecryptByReceiver expects.
+func CombineFragments(cFrags []*CapsuleFragment, threshold int) (APrime curve.Point, err error) {
+	if threshold < 1 {
+		return nil, ErrInvalidThreshold
+	}
+	if len(cFrags) < threshold {
+		return nil, ErrNotEnoughFragments
+	}
+	ids := make([]*big.Int, 0, threshold)
+	distinct := make([]*CapsuleFragment, 0, threshold)
+	seen := make(map[string]bool, threshold)
+	for i := 0; i < len(cFrags) && len(distinct) < threshold; i++ {
+		if !validFragment(cFrags[i]) {
+			return nil, ErrInvalidFragment
+		}
+		if seen[cFrags[i].ID.String()] {
+			continue
+		}
+		seen[cFrags[i].ID.String()] = true
+		ids = append(ids, cFrags[i].ID)
+		distinct = append(distinct, cFrags[i])
+	}
+	if len(distinct) < threshold {
+		return nil, ErrDuplicateFragment
+	}
+	cFrags = distinct
+	// APrime = sum(lambda_i * APrime_i) = f(0) * e(A, G2)
+	APrime = newPairedPoint().ScalarMult(cFrags[0].APrime, lagrangeCoefficient(ids, 0))
+	for i := 1; i < len(cFrags); i++ {
+		term := newPairedPoint().ScalarMult(cFrags[i].APrime, lagrangeCoefficient(ids, i))
+		APrime.Add(APrime, term)
+	}
+	return APrime, nil
+}
+
+// validFragment reports whether cFrag has an ID in [1, curve.Order), as the
+// share at a zero ID is f(0) itself and breaks the interpolation, and a GT
+// APrime.
+func validFragment(cFrag *CapsuleFragment) bool {
+	return cFrag != nil && cFrag.ID != nil && cFrag.ID.Sign() > 0 && cFrag.ID.Cmp(curve.Order) < 0 && isGT(cFrag.APrime)
+}
+
+// evaluatePolynomial evaluates sum(coeffs[i] * x^i) mod Order.
+func evaluatePolynomial(coeffs []*big.Int, x *big.Int) *big.Int {
+	y := new(big.Int)
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		y.Mul(y, x).Add(y, coeffs[i]).Mod(y, curve.Order)
+	}
+	return y
+}
+
+// lagrangeCoefficient calculates the coefficient of ids[i] for interpolating at 0:
+// lambda_i = prod(x_j / (x_j - x_i)), j != i
+func lagrangeCoefficient(ids []*big.Int, i int) *big.Int {
+	num, den := big.NewInt(1), big.NewInt(1)
+	diff := new(big.Int)
+	for j := range ids {
+		if j == i {
+			continue
+		}
+		num.Mul(num, ids[j]).Mod(num, curve.Order)
+		diff.Sub(ids[j], ids[i]).Mod(diff, curve.Order)
+		den.Mul(den, diff).Mod(den, curve.Order)
+	}
+	den.ModInverse(den, curve.Order)
+	return num.Mul(num, den).Mod(num, curve.Order)
+}
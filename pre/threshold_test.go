@@ -0,0 +1,151 @@
+package pre_test
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/pre"
+)
+
+func TestThresholdReEncrypt(t *testing.T) {
+	var threshold, n = 3, 5
+
+	a, _, b, A, plaintext, ciphertext := prepareCapsule(t)
+
+	// step 1: split re-key
+	kFrags, err := pre.GenerateKeyFragments(a, b, threshold, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kFrags) != n {
+		t.Fatalf("want %d key fragments, got %d", n, len(kFrags))
+	}
+
+	// step 2: every proxy re-encrypts
+	cFrags := make([]*pre.CapsuleFragment, n)
+	for i := range kFrags {
+		cFrags[i] = pre.ReEncryptFragment(A, kFrags[i])
+	}
+
+	// step 3: any threshold fragments combine into the single-proxy APrime
+	expected := pre.ReEncrypt(A, pre.GenerateReKey(a, b))
+	subsets := [][]int{{0, 1, 2}, {2, 3, 4}, {4, 0, 3}, {1, 3, 4, 0}}
+	for _, subset := range subsets {
+		selected := make([]*pre.CapsuleFragment, len(subset))
+		for i, idx := range subset {
+			selected[i] = cFrags[idx]
+		}
+		APrime, err := pre.CombineFragments(selected, threshold)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if APrime.String() != expected.String() {
+			t.Errorf("subset %v: combined APrime not equal to re-encrypted APrime", subset)
+		}
+
+		// step 4: decrypt by receiver
+		deBuf := bytes.NewBuffer(nil)
+		err = pre.DecryptByReceiver(APrime, b, pre.NewDecryptClosure(bytes.NewReader(ciphertext), deBuf))
+		if err != nil {
+			t.Fatalf("subset %v: %v", subset, err)
+		}
+		if !bytes.Equal(plaintext, deBuf.Bytes()) {
+			t.Errorf("subset %v: decrypt by receiver error", subset)
+		}
+	}
+}
+
+func TestThresholdFailures(t *testing.T) {
+	var threshold, n = 3, 5
+
+	a, pkA, b, A, _, ciphertext := prepareCapsule(t)
+
+	// invalid thresholds
+	for _, k := range []int{0, -1, n + 1} {
+		if _, err := pre.GenerateKeyFragments(a, b, k, n); !errors.Is(err, pre.ErrInvalidThreshold) {
+			t.Errorf("threshold %d: want ErrInvalidThreshold, got %v", k, err)
+		}
+	}
+
+	kFrags, err := pre.GenerateKeyFragments(a, b, threshold, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cFrags := make([]*pre.CapsuleFragment, n)
+	for i := range kFrags {
+		cFrags[i] = pre.ReEncryptFragment(A, kFrags[i])
+	}
+
+	// less than threshold fragments
+	if _, err = pre.CombineFragments(cFrags[:threshold-1], threshold); !errors.Is(err, pre.ErrNotEnoughFragments) {
+		t.Errorf("want ErrNotEnoughFragments, got %v", err)
+	}
+	if _, err = pre.CombineFragments(cFrags, 0); !errors.Is(err, pre.ErrInvalidThreshold) {
+		t.Errorf("want ErrInvalidThreshold, got %v", err)
+	}
+
+	// duplicate fragments
+	dup := []*pre.CapsuleFragment{cFrags[0], cFrags[1], cFrags[0]}
+	if _, err = pre.CombineFragments(dup, threshold); !errors.Is(err, pre.ErrDuplicateFragment) {
+		t.Errorf("want ErrDuplicateFragment, got %v", err)
+	}
+
+	// duplicates are skipped when enough distinct fragments follow
+	APrime, err := pre.CombineFragments(append(dup, cFrags[3]), threshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = decryptAPrime(APrime, b, ciphertext); err != nil {
+		t.Errorf("decrypt with duplicated fragments: %v", err)
+	}
+
+	// malformed fragments are rejected, not combined
+	malformed := []*pre.CapsuleFragment{
+		nil,
+		{APrime: cFrags[2].APrime},
+		{ID: big.NewInt(0), APrime: cFrags[2].APrime},
+		{ID: new(big.Int).Set(curve.Order), APrime: cFrags[2].APrime},
+		{ID: cFrags[2].ID, APrime: A},
+		{ID: cFrags[2].ID, APrime: (*curve.GT)(nil)},
+	}
+	for i, cFrag := range malformed {
+		if _, err = pre.CombineFragments([]*pre.CapsuleFragment{cFrags[0], cFrags[1], cFrag}, threshold); !errors.Is(err, pre.ErrInvalidFragment) {
+			t.Errorf("malformed fragment %d: want ErrInvalidFragment, got %v", i, err)
+		}
+	}
+
+	// colluding proxies below the threshold learn nothing useful
+	APrime, err = pre.CombineFragments(cFrags[:threshold-1], threshold-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = decryptAPrime(APrime, b, ciphertext); err == nil {
+		t.Error("decrypted with less than threshold fragments")
+	}
+
+	// fragments of another capsule do not combine
+	other := pre.ReEncryptFragment(curve.NewPoint(curve.TypeG1).ScalarMult(pkA, big.NewInt(7)), kFrags[2])
+	APrime, err = pre.CombineFragments([]*pre.CapsuleFragment{cFrags[0], cFrags[1], other}, threshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = decryptAPrime(APrime, b, ciphertext); err == nil {
+		t.Error("decrypted with a fragment of another capsule")
+	}
+
+	// fragments of another re-key do not combine
+	otherFrags, err := pre.GenerateKeyFragments(a, b, threshold, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mixed := []*pre.CapsuleFragment{cFrags[0], cFrags[1], pre.ReEncryptFragment(A, otherFrags[2])}
+	if APrime, err = pre.CombineFragments(mixed, threshold); err != nil {
+		t.Fatal(err)
+	}
+	if err = decryptAPrime(APrime, b, ciphertext); err == nil {
+		t.Error("decrypted with fragments of different re-keys")
+	}
+}
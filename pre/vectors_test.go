@@ -0,0 +1,335 @@
+package pre_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"math/big"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/pre"
+)
+
+var update = flag.Bool("update", false, "regenerate testdata/vectors.json")
+
+const vectorsFile = "testdata/vectors.json"
+
+// preVectors are the known-answer vectors of PRE. Scalars are 32-byte big
+// endian, points are curve.Point.Marshal(), all hex encoded. Encrypt reads r
+// then iv from its randomness source, EncryptCCA reads r, iv then w, and
+// GenerateKeyFragments reads the coefficients then the IDs.
+type preVectors struct {
+	Comment   string            `json:"comment"`
+	Vectors   []preVector       `json:"vectors"`
+	CCA       []ccaVector       `json:"cca"`
+	Threshold []thresholdVector `json:"threshold"`
+}
+
+type preVector struct {
+	A          string `json:"a"`
+	B          string `json:"b"`
+	R          string `json:"r"`
+	IV         string `json:"iv"`
+	Plaintext  string `json:"plaintext"`
+	PublicKey  string `json:"public_key"` // a * G1
+	ReKey      string `json:"re_key"`     // (b/a) * G2
+	Capsule    string `json:"capsule"`    // A = r * pkA
+	ReCapsule  string `json:"re_capsule"` // A' = e(A, rkAB)
+	Ciphertext string `json:"ciphertext"` // iv || AES-256-CBC || HMAC-SHA256
+}
+
+type ccaVector struct {
+	A          string `json:"a"`
+	R          string `json:"r"`
+	IV         string `json:"iv"`
+	W          string `json:"w"`
+	Plaintext  string `json:"plaintext"`
+	Capsule    string `json:"capsule"` // A = r * pkA
+	Digest     string `json:"digest"`
+	C          string `json:"c"`
+	Z          string `json:"z"` // Z = w + C*r
+	Ciphertext string `json:"ciphertext"`
+}
+
+type thresholdVector struct {
+	A            string   `json:"a"`
+	B            string   `json:"b"`
+	N            int      `json:"n"`
+	Coefficients []string `json:"coefficients"` // c1 ... c(k-1), so threshold k
+	IDs          []string `json:"ids"`
+	Capsule      string   `json:"capsule"`       // A, a G1 point
+	KeyFragments []string `json:"key_fragments"` // f(ID) * G2
+	ReCapsule    string   `json:"re_capsule"`    // combined from the first k fragments
+}
+
+func TestVectors(t *testing.T) {
+	if *update {
+		writeVectors(t)
+	}
+	data, err := ioutil.ReadFile(vectorsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors preVectors
+	if err = json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors.Vectors) == 0 {
+		t.Fatal("no vectors")
+	}
+	for i, v := range vectors.Vectors {
+		got, err := computeVector(decodeHex(t, v.A), decodeHex(t, v.B), decodeHex(t, v.R), decodeHex(t, v.IV), decodeHex(t, v.Plaintext))
+		if err != nil {
+			t.Fatalf("vector %d: %v", i, err)
+		}
+		if *got != v {
+			t.Errorf("vector %d: mismatch\nwant: %+v\ngot:  %+v", i, v, *got)
+		}
+
+		// both decryption paths recover the plaintext
+		a, b := new(big.Int).SetBytes(decodeHex(t, v.A)), new(big.Int).SetBytes(decodeHex(t, v.B))
+		A, APrime := curve.NewPoint(curve.TypeG1), curve.NewPoint(curve.TypeGT)
+		if _, err = A.Unmarshal(decodeHex(t, v.Capsule)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = APrime.Unmarshal(decodeHex(t, v.ReCapsule)); err != nil {
+			t.Fatal(err)
+		}
+		deBuf := bytes.NewBuffer(nil)
+		if err = pre.DecryptByReceiver(APrime, b, pre.NewDecryptClosure(bytes.NewReader(decodeHex(t, v.Ciphertext)), deBuf)); err != nil {
+			t.Fatalf("vector %d: %v", i, err)
+		}
+		if hex.EncodeToString(deBuf.Bytes()) != v.Plaintext {
+			t.Errorf("vector %d: decrypt by receiver error", i)
+		}
+		deBuf.Reset()
+		if err = pre.DecryptByOwner(A, a, pre.NewDecryptClosure(bytes.NewReader(decodeHex(t, v.Ciphertext)), deBuf)); err != nil {
+			t.Fatalf("vector %d: %v", i, err)
+		}
+		if hex.EncodeToString(deBuf.Bytes()) != v.Plaintext {
+			t.Errorf("vector %d: decrypt by owner error", i)
+		}
+	}
+}
+
+func TestCCAVectors(t *testing.T) {
+	data, err := ioutil.ReadFile(vectorsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors preVectors
+	if err = json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors.CCA) == 0 {
+		t.Fatal("no cca vectors")
+	}
+	for i, v := range vectors.CCA {
+		got, err := computeCCAVector(decodeHex(t, v.A), decodeHex(t, v.R), decodeHex(t, v.IV), decodeHex(t, v.W), decodeHex(t, v.Plaintext))
+		if err != nil {
+			t.Fatalf("cca vector %d: %v", i, err)
+		}
+		if *got != v {
+			t.Errorf("cca vector %d: mismatch\nwant: %+v\ngot:  %+v", i, v, *got)
+		}
+	}
+}
+
+func computeCCAVector(a, r, iv, w, plaintext []byte) (*ccaVector, error) {
+	params := &pre.Params{Rand: bytes.NewReader(append(append(append([]byte(nil), r...), iv...), w...))}
+	pkA := curve.NewPoint(curve.TypeG1).ScalarBaseMult(new(big.Int).SetBytes(a))
+	cipherBuf := bytes.NewBuffer(nil)
+	capsule, err := params.EncryptCCA(pkA, bytes.NewReader(plaintext), cipherBuf)
+	if err != nil {
+		return nil, err
+	}
+	if err = capsule.Verify(pkA); err != nil {
+		return nil, err
+	}
+	return &ccaVector{
+		A:          hex.EncodeToString(a),
+		R:          hex.EncodeToString(r),
+		IV:         hex.EncodeToString(iv),
+		W:          hex.EncodeToString(w),
+		Plaintext:  hex.EncodeToString(plaintext),
+		Capsule:    hex.EncodeToString(capsule.A.Marshal()),
+		Digest:     hex.EncodeToString(capsule.Digest[:]),
+		C:          scalarHex(capsule.C),
+		Z:          scalarHex(capsule.Z),
+		Ciphertext: hex.EncodeToString(cipherBuf.Bytes()),
+	}, nil
+}
+
+func TestThresholdVectors(t *testing.T) {
+	data, err := ioutil.ReadFile(vectorsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors preVectors
+	if err = json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors.Threshold) == 0 {
+		t.Fatal("no threshold vectors")
+	}
+	for i, v := range vectors.Threshold {
+		got, err := computeThresholdVector(t, v.A, v.B, v.Capsule, v.N, v.Coefficients, v.IDs)
+		if err != nil {
+			t.Fatalf("threshold vector %d: %v", i, err)
+		}
+		if gotJSON, wantJSON := mustJSON(t, got), mustJSON(t, &v); gotJSON != wantJSON {
+			t.Errorf("threshold vector %d: mismatch\nwant: %s\ngot:  %s", i, wantJSON, gotJSON)
+		}
+		// the combined fragments equal the single-proxy re-encryption
+		a, b := new(big.Int).SetBytes(decodeHex(t, v.A)), new(big.Int).SetBytes(decodeHex(t, v.B))
+		A := curve.NewPoint(curve.TypeG1)
+		if _, err = A.Unmarshal(decodeHex(t, v.Capsule)); err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(pre.ReEncrypt(A, pre.GenerateReKey(a, b)).Marshal()) != v.ReCapsule {
+			t.Errorf("threshold vector %d: combined APrime not equal to re-encrypted APrime", i)
+		}
+	}
+}
+
+func computeThresholdVector(t *testing.T, a, b, capsule string, n int, coeffs, ids []string) (*thresholdVector, error) {
+	random := bytes.NewBuffer(nil)
+	for _, k := range append(append([]string(nil), coeffs...), ids...) {
+		random.Write(decodeHex(t, k))
+	}
+	params := &pre.Params{Rand: random}
+	kFrags, err := params.GenerateKeyFragments(new(big.Int).SetBytes(decodeHex(t, a)), new(big.Int).SetBytes(decodeHex(t, b)), len(coeffs)+1, n)
+	if err != nil {
+		return nil, err
+	}
+	A := curve.NewPoint(curve.TypeG1)
+	if _, err = A.Unmarshal(decodeHex(t, capsule)); err != nil {
+		return nil, err
+	}
+	v := &thresholdVector{A: a, B: b, N: n, Coefficients: coeffs, Capsule: capsule}
+	cFrags := make([]*pre.CapsuleFragment, len(kFrags))
+	for i, kFrag := range kFrags {
+		v.IDs = append(v.IDs, scalarHex(kFrag.ID))
+		v.KeyFragments = append(v.KeyFragments, hex.EncodeToString(kFrag.K.Marshal()))
+		cFrags[i] = pre.ReEncryptFragment(A, kFrag)
+	}
+	APrime, err := pre.CombineFragments(cFrags, len(coeffs)+1)
+	if err != nil {
+		return nil, err
+	}
+	v.ReCapsule = hex.EncodeToString(APrime.Marshal())
+	return v, nil
+}
+
+func computeVector(a, b, r, iv, plaintext []byte) (*preVector, error) {
+	params := &pre.Params{Rand: bytes.NewReader(append(append([]byte(nil), r...), iv...))}
+	pkA := curve.NewPoint(curve.TypeG1).ScalarBaseMult(new(big.Int).SetBytes(a))
+	rkAB := pre.GenerateReKey(new(big.Int).SetBytes(a), new(big.Int).SetBytes(b))
+	cipherBuf := bytes.NewBuffer(nil)
+	A, err := params.Encrypt(pkA, params.NewEncryptClosure(bytes.NewReader(plaintext), cipherBuf))
+	if err != nil {
+		return nil, err
+	}
+	return &preVector{
+		A:          hex.EncodeToString(a),
+		B:          hex.EncodeToString(b),
+		R:          hex.EncodeToString(r),
+		IV:         hex.EncodeToString(iv),
+		Plaintext:  hex.EncodeToString(plaintext),
+		PublicKey:  hex.EncodeToString(pkA.Marshal()),
+		ReKey:      hex.EncodeToString(rkAB.Marshal()),
+		Capsule:    hex.EncodeToString(A.Marshal()),
+		ReCapsule:  hex.EncodeToString(pre.ReEncrypt(A, rkAB).Marshal()),
+		Ciphertext: hex.EncodeToString(cipherBuf.Bytes()),
+	}, nil
+}
+
+func writeVectors(t *testing.T) {
+	vectors := preVectors{
+		Comment: "scalars are 32-byte big endian, points are Marshal() encoded, Encrypt reads r then iv, EncryptCCA reads r, iv then w, GenerateKeyFragments reads the coefficients then the ids",
+	}
+	for _, size := range []int{0, 15, 16, 100} {
+		plaintext := make([]byte, size)
+		iv := make([]byte, 16)
+		if _, err := rand.Read(plaintext); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rand.Read(iv); err != nil {
+			t.Fatal(err)
+		}
+		v, err := computeVector(randomScalar(t), randomScalar(t), randomScalar(t), iv, plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vectors.Vectors = append(vectors.Vectors, *v)
+	}
+	for _, size := range []int{0, 100} {
+		plaintext := make([]byte, size)
+		iv := make([]byte, 16)
+		if _, err := rand.Read(plaintext); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rand.Read(iv); err != nil {
+			t.Fatal(err)
+		}
+		v, err := computeCCAVector(randomScalar(t), randomScalar(t), iv, randomScalar(t), plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vectors.CCA = append(vectors.CCA, *v)
+	}
+	for _, k := range []int{1, 3} {
+		coeffs, ids := make([]string, k-1), make([]string, 5)
+		for i := range coeffs {
+			coeffs[i] = hex.EncodeToString(randomScalar(t))
+		}
+		for i := range ids {
+			ids[i] = hex.EncodeToString(randomScalar(t))
+		}
+		A := curve.NewPoint(curve.TypeG1).ScalarBaseMult(new(big.Int).SetBytes(randomScalar(t)))
+		v, err := computeThresholdVector(t, hex.EncodeToString(randomScalar(t)), hex.EncodeToString(randomScalar(t)), hex.EncodeToString(A.Marshal()), len(ids), coeffs, ids)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vectors.Threshold = append(vectors.Threshold, *v)
+	}
+	data, err := json.MarshalIndent(&vectors, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(vectorsFile, append(data, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func randomScalar(t *testing.T) []byte {
+	k, err := curve.RandomFieldElement(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(make([]byte, 32-len(k.Bytes())), k.Bytes()...)
+}
+
+func scalarHex(k *big.Int) string {
+	return hex.EncodeToString(append(make([]byte, 32-len(k.Bytes())), k.Bytes()...))
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
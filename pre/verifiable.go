@@ -0,0 +1,90 @@
+package pre
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"github.com/clarenous/proxyot/curve"
+)
+
+const domainReEncryptionProof = "proxyot/pre/re-encryption-proof"
+
+var errInvalidOwnerKey = errors.New("owner public key is not a G1 point")
+
+// ReEncryptionProof proves that APrime = e(A, rk) for the re-key rk committed
+// by the delegator as V = e(pkA, rk), without revealing rk.
+//
+// It is a Chaum-Pedersen style proof for the homomorphism X -> (e(A, X), e(pkA, X))
+// from G2 to GT x GT. Since pairing with pkA is injective on G2, a valid proof
+// binds APrime to the committed re-key.
+type ReEncryptionProof struct {
+	C *big.Int    // challenge
+	Z curve.Point // response, Z = W + C * rk
+}
+
+// CommitReKey returns the delegator commitment V = e(pkA, rkAB) of a re-key.
+func CommitReKey(pkA, rkAB curve.Point) (V curve.Point, err error) {
+	if !isG1(pkA) {
+		return nil, errInvalidOwnerKey
+	}
+	if !isG2(rkAB) {
+		return nil, errInvalidReKeyPoint
+	}
+	return ReEncrypt(pkA, rkAB), nil
+}
+
+// ReceiverReKeyCommitment returns the commitment of an honest re-key towards
+// receiver b: V = e(a*G1, (b/a)*G2) = b*Gt. A receiver uses it to verify proofs
+// without trusting any commitment published by others.
+func ReceiverReKeyCommitment(b *big.Int) curve.Point {
+	return newPairedPoint().ScalarBaseMult(b)
+}
+
+// ReEncryptWithProof re-encrypts A like ReEncrypt and proves it was done with rkAB.
+func ReEncryptWithProof(A, rkAB, pkA curve.Point) (APrime curve.Point, proof *ReEncryptionProof, err error) {
+	if !isG1(A) {
+		return nil, nil, errInvalidCapsulePoint
+	}
+	V, err := CommitReKey(pkA, rkAB)
+	if err != nil {
+		return nil, nil, err
+	}
+	// random W = w*G2
+	w, err := curve.RandomFieldElement(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	W := newTwistPoint().ScalarBaseMult(w)
+	// commitments T1 = e(A, W), T2 = e(pkA, W)
+	T1, T2 := ReEncrypt(A, W), ReEncrypt(pkA, W)
+	APrime = ReEncrypt(A, rkAB)
+	c := reEncryptionChallenge(A, APrime, pkA, V, T1, T2)
+	// response Z = W + c * rk
+	Z := newTwistPoint().ScalarMult(rkAB, c)
+	Z.Add(Z, W)
+	return APrime, &ReEncryptionProof{C: c, Z: Z}, nil
+}
+
+// VerifyReEncryption verifies that APrime was re-encrypted from A with the
+// re-key committed as V.
+func VerifyReEncryption(A, APrime, pkA, V curve.Point, proof *ReEncryptionProof) bool {
+	if proof == nil || proof.C == nil || !isG2(proof.Z) {
+		return false
+	}
+	if !isG1(A) || !isG1(pkA) || !isGT(APrime) || !isGT(V) {
+		return false
+	}
+	// T1 = e(A, Z) - c*APrime, T2 = e(pkA, Z) - c*V
+	T1 := ReEncrypt(A, proof.Z)
+	T1.Add(T1, newPairedPoint().Neg(newPairedPoint().ScalarMult(APrime, proof.C)))
+	T2 := ReEncrypt(pkA, proof.Z)
+	T2.Add(T2, newPairedPoint().Neg(newPairedPoint().ScalarMult(V, proof.C)))
+	c := reEncryptionChallenge(A, APrime, pkA, V, T1, T2)
+	return c.Cmp(proof.C) == 0
+}
+
+func reEncryptionChallenge(A, APrime, pkA, V, T1, T2 curve.Point) *big.Int {
+	return curve.DeriveChallenge(domainReEncryptionProof,
+		A.Marshal(), APrime.Marshal(), pkA.Marshal(), V.Marshal(), T1.Marshal(), T2.Marshal())
+}
@@ -0,0 +1,123 @@
+package pre_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/clarenous/proxyot/curve"
+	"github.com/clarenous/proxyot/pre"
+)
+
+func TestVerifiableReEncrypt(t *testing.T) {
+	a, pkA, b, A, plaintext, ciphertext := prepareCapsule(t)
+
+	// alice generates and commits the re-key
+	rkAB := pre.GenerateReKey(a, b)
+	V, err := pre.CommitReKey(pkA, rkAB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if V.String() != pre.ReceiverReKeyCommitment(b).String() {
+		t.Fatal("delegator commitment not equal to receiver commitment")
+	}
+
+	// proxy re-encrypts with proof
+	APrime, proof, err := pre.ReEncryptWithProof(A, rkAB, pkA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if APrime.String() != pre.ReEncrypt(A, rkAB).String() {
+		t.Fatal("APrime not equal to re-encrypted APrime")
+	}
+
+	// bob verifies before downloading the ciphertext
+	if !pre.VerifyReEncryption(A, APrime, pkA, V, proof) {
+		t.Fatal("verify with delegator commitment failed")
+	}
+	if !pre.VerifyReEncryption(A, APrime, pkA, pre.ReceiverReKeyCommitment(b), proof) {
+		t.Fatal("verify with receiver commitment failed")
+	}
+
+	deBuf := bytes.NewBuffer(nil)
+	err = pre.DecryptByReceiver(APrime, b, pre.NewDecryptClosure(bytes.NewReader(ciphertext), deBuf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, deBuf.Bytes()) {
+		t.Errorf("decrypt by receiver error")
+	}
+}
+
+func TestVerifiableReEncryptCheating(t *testing.T) {
+	a, pkA, b, A, _, _ := prepareCapsule(t)
+	rkAB := pre.GenerateReKey(a, b)
+	V, err := pre.CommitReKey(pkA, rkAB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	APrime, proof, err := pre.ReEncryptWithProof(A, rkAB, pkA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// garbage APrime
+	_, garbage, err := curve.NewRandomPoint(curve.TypeGT, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pre.VerifyReEncryption(A, garbage, pkA, V, proof) {
+		t.Error("verified garbage APrime")
+	}
+
+	// right re-key, wrong capsule
+	otherA := curve.NewPoint(curve.TypeG1).ScalarMult(pkA, big.NewInt(7))
+	otherAPrime, otherProof, err := pre.ReEncryptWithProof(otherA, rkAB, pkA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pre.VerifyReEncryption(A, otherAPrime, pkA, V, otherProof) {
+		t.Error("verified APrime of another capsule")
+	}
+
+	// right capsule, wrong re-key
+	_, lazyKey, err := curve.NewRandomPoint(curve.TypeG2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lazyAPrime, lazyProof, err := pre.ReEncryptWithProof(A, lazyKey, pkA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pre.VerifyReEncryption(A, lazyAPrime, pkA, V, lazyProof) {
+		t.Error("verified APrime of another re-key")
+	}
+
+	// tampered proofs
+	tampered := &pre.ReEncryptionProof{C: new(big.Int).Add(proof.C, big.NewInt(1)), Z: proof.Z}
+	if pre.VerifyReEncryption(A, APrime, pkA, V, tampered) {
+		t.Error("verified tampered challenge")
+	}
+	tampered = &pre.ReEncryptionProof{C: proof.C, Z: curve.NewPoint(curve.TypeG2).Add(proof.Z, rkAB)}
+	if pre.VerifyReEncryption(A, APrime, pkA, V, tampered) {
+		t.Error("verified tampered response")
+	}
+	if pre.VerifyReEncryption(A, APrime, pkA, V, nil) {
+		t.Error("verified nil proof")
+	}
+
+	// points of the wrong type are rejected, not paired
+	if _, _, err = pre.ReEncryptWithProof(rkAB, rkAB, pkA); err == nil {
+		t.Error("re-encrypted a G2 capsule")
+	}
+	if _, _, err = pre.ReEncryptWithProof(A, A, pkA); err == nil {
+		t.Error("re-encrypted with a G1 re-key")
+	}
+	if _, _, err = pre.ReEncryptWithProof(A, rkAB, (*curve.G1)(nil)); err == nil {
+		t.Error("re-encrypted with a nil owner key")
+	}
+	if pre.VerifyReEncryption(rkAB, APrime, pkA, V, proof) || pre.VerifyReEncryption(A, A, pkA, V, proof) {
+		t.Error("verified points of the wrong type")
+	}
+}
@@ -0,0 +1,133 @@
+package redactable
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/clarenous/proxyot/chash"
+	"github.com/clarenous/proxyot/merkle"
+)
+
+// SidecarSuffix is appended to the name of a file to name its metadata.
+const SidecarSuffix = ".rdmeta"
+
+// Metadata is the sidecar of a redactable file.
+type Metadata struct {
+	PublicKey  *chash.PublicKey
+	BlockSize  int64
+	Root       merkle.Hash
+	Hashes     []chash.ChameleonHash // Merkle leaves, one per block
+	Randomness []*chash.Randomness   // one per block
+}
+
+// Public returns the metadata with public randomness, for verifiers.
+func (meta *Metadata) Public() *Metadata {
+	public := *meta
+	public.Randomness = make([]*chash.Randomness, len(meta.Randomness))
+	for i := range meta.Randomness {
+		public.Randomness[i] = meta.Randomness[i].Public()
+	}
+	return &public
+}
+
+type metadataJSON struct {
+	PublicKey  string   `json:"public_key"`
+	BlockSize  int64    `json:"block_size"`
+	Root       string   `json:"root"`
+	Hashes     []string `json:"hashes"`
+	Randomness []string `json:"randomness"`
+}
+
+// MarshalJSON encodes the metadata with hex encoded keys, hashes and
+// randomness.
+func (meta *Metadata) MarshalJSON() ([]byte, error) {
+	v := &metadataJSON{
+		PublicKey:  hex.EncodeToString(meta.PublicKey.Marshal()),
+		BlockSize:  meta.BlockSize,
+		Root:       meta.Root.String(),
+		Hashes:     make([]string, len(meta.Hashes)),
+		Randomness: make([]string, len(meta.Randomness)),
+	}
+	for i := range meta.Hashes {
+		v.Hashes[i] = meta.Hashes[i].String()
+	}
+	for i := range meta.Randomness {
+		v.Randomness[i] = hex.EncodeToString(meta.Randomness[i].Marshal())
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes metadata encoded by MarshalJSON.
+func (meta *Metadata) UnmarshalJSON(data []byte) error {
+	var v metadataJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if len(v.Hashes) != len(v.Randomness) {
+		return ErrInvalidMetadata
+	}
+	b, err := hex.DecodeString(v.PublicKey)
+	if err != nil {
+		return err
+	}
+	pk := &chash.PublicKey{}
+	if err = pk.Unmarshal(b); err != nil {
+		return err
+	}
+	var root merkle.Hash
+	if err = decodeHash(v.Root, root[:]); err != nil {
+		return err
+	}
+	hashes := make([]chash.ChameleonHash, len(v.Hashes))
+	randomness := make([]*chash.Randomness, len(v.Randomness))
+	for i := range hashes {
+		if err = decodeHash(v.Hashes[i], hashes[i][:]); err != nil {
+			return err
+		}
+		if b, err = hex.DecodeString(v.Randomness[i]); err != nil {
+			return err
+		}
+		randomness[i] = &chash.Randomness{}
+		if err = randomness[i].Unmarshal(b); err != nil {
+			return err
+		}
+	}
+	meta.PublicKey, meta.BlockSize, meta.Root = pk, v.BlockSize, root
+	meta.Hashes, meta.Randomness = hashes, randomness
+	return nil
+}
+
+// SaveMetadata writes the metadata of filename to its sidecar.
+func SaveMetadata(filename string, meta *Metadata) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename+SidecarSuffix, data, 0600)
+}
+
+// LoadMetadata reads the metadata of filename from its sidecar.
+func LoadMetadata(filename string) (*Metadata, error) {
+	data, err := ioutil.ReadFile(filename + SidecarSuffix)
+	if err != nil {
+		return nil, err
+	}
+	meta := &Metadata{}
+	if err = json.Unmarshal(data, meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+func decodeHash(s string, dst []byte) error {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	if len(b) != len(dst) {
+		return ErrInvalidMetadata
+	}
+	copy(dst, b)
+	return nil
+}
@@ -0,0 +1,172 @@
+// Package redactable authenticates a file by a Merkle tree over the
+// chameleon hashes of its blocks. The holder of the chameleon trapdoor
+// replaces a block by a collision of its hash, so the leaves and the root of
+// the tree stay the same and the proofs of other blocks stay valid.
+//
+// The per-block randomness is kept in sidecar metadata next to the file. It
+// holds the scalar randomness needed for collisions, and must be kept as
+// private as the trapdoor; verifiers are given Metadata.Public.
+package redactable
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+
+	"github.com/clarenous/proxyot/chash"
+	"github.com/clarenous/proxyot/fileobj"
+	"github.com/clarenous/proxyot/merkle"
+)
+
+var (
+	// ErrInvalidProof occurs when a block does not verify against the root.
+	ErrInvalidProof = errors.New("invalid block proof")
+
+	// ErrInvalidMetadata occurs when metadata does not match its file.
+	ErrInvalidMetadata = errors.New("invalid redactable file metadata")
+
+	// ErrEmptyFile occurs when building a file without blocks.
+	ErrEmptyFile = errors.New("empty file")
+)
+
+// Proof proves a block of a file against the root.
+type Proof struct {
+	Randomness *chash.Randomness // public randomness of the block
+	Path       *merkle.Proof
+}
+
+// File is a file authenticated by the root of its metadata.
+type File struct {
+	obj    fileobj.Object
+	hasher *chash.Hasher
+	meta   *Metadata
+	tree   *merkle.Tree
+}
+
+// Build hashes every block of obj under fresh randomness read from r, or from
+// crypto/rand.Reader if r is nil, and builds the Merkle tree.
+func Build(obj fileobj.Object, td *chash.Trapdoor, r io.Reader) (*File, error) {
+	if obj.BlockCount() == 0 {
+		return nil, ErrEmptyFile
+	}
+	if r == nil {
+		r = rand.Reader
+	}
+	file := &File{
+		obj:    obj,
+		hasher: chash.NewTrapdoorHasher(td),
+		meta: &Metadata{
+			PublicKey:  td.PublicKey(),
+			BlockSize:  obj.BlockSize(),
+			Randomness: make([]*chash.Randomness, obj.BlockCount()),
+			Hashes:     make([]chash.ChameleonHash, obj.BlockCount()),
+		},
+	}
+	for i := range file.meta.Hashes {
+		data, err := obj.GetBlock(int64(i))
+		if err != nil {
+			return nil, err
+		}
+		if file.meta.Randomness[i], err = chash.NewRandomness(r); err != nil {
+			return nil, err
+		}
+		file.meta.Hashes[i] = file.hasher.Hash(data, file.meta.Randomness[i])
+	}
+	file.buildTree()
+	file.meta.Root = *file.tree.Root
+	return file, nil
+}
+
+// Open opens obj with its metadata. Without trapdoor, td is nil, the file is
+// verified but not updated. The blocks are not hashed again, see VerifyBlock.
+func Open(obj fileobj.Object, meta *Metadata, td *chash.Trapdoor) (*File, error) {
+	if meta.BlockSize != obj.BlockSize() || int64(len(meta.Hashes)) != obj.BlockCount() ||
+		len(meta.Randomness) != len(meta.Hashes) || len(meta.Hashes) == 0 {
+		return nil, ErrInvalidMetadata
+	}
+	file := &File{obj: obj, hasher: chash.NewHasher(meta.PublicKey), meta: meta}
+	if td != nil {
+		if string(td.PublicKey().Marshal()) != string(meta.PublicKey.Marshal()) {
+			return nil, ErrInvalidMetadata
+		}
+		file.hasher = chash.NewTrapdoorHasher(td)
+	}
+	file.buildTree()
+	if *file.tree.Root != meta.Root {
+		return nil, ErrInvalidMetadata
+	}
+	return file, nil
+}
+
+// Root returns the root of the file.
+func (file *File) Root() merkle.Hash {
+	return file.meta.Root
+}
+
+// Metadata returns the metadata of the file, to be saved as its sidecar.
+func (file *File) Metadata() *Metadata {
+	return file.meta
+}
+
+// Proof returns the proof of block i.
+func (file *File) Proof(i int64) (*Proof, error) {
+	if i < 0 || i >= int64(len(file.meta.Hashes)) {
+		return nil, fileobj.ErrOutOfBlockIndex
+	}
+	return &Proof{
+		Randomness: file.meta.Randomness[i].Public(),
+		Path:       file.tree.Proof(int(i)),
+	}, nil
+}
+
+// UpdateBlock replaces block i by data under the randomness of a collision,
+// keeping its chameleon hash and the root.
+func (file *File) UpdateBlock(i int64, data []byte) error {
+	if i < 0 || i >= int64(len(file.meta.Hashes)) {
+		return fileobj.ErrOutOfBlockIndex
+	}
+	if int64(len(data)) != file.meta.BlockSize {
+		return fileobj.ErrWrongBlockSize
+	}
+	old, err := file.obj.GetBlock(i)
+	if err != nil {
+		return err
+	}
+	ch, rd, err := file.hasher.Collide(file.meta.Randomness[i], old, data)
+	if err != nil {
+		return err
+	}
+	if !ch.Equals(file.meta.Hashes[i]) {
+		return ErrInvalidMetadata
+	}
+	if err = file.obj.SetBlock(i, data); err != nil {
+		return err
+	}
+	file.meta.Randomness[i] = rd
+	return nil
+}
+
+// VerifyBlock verifies data as block i of the file.
+func (file *File) VerifyBlock(i int64, data []byte, proof *Proof) error {
+	return VerifyBlock(file.meta.PublicKey, file.meta.Root, i, data, proof)
+}
+
+// VerifyBlock verifies data as block i of the file of root, hashed under pk.
+func VerifyBlock(pk *chash.PublicKey, root merkle.Hash, i int64, data []byte, proof *Proof) error {
+	if proof == nil || proof.Randomness == nil || proof.Path == nil || int64(proof.Path.Index) != i {
+		return ErrInvalidProof
+	}
+	leaf := merkle.Hash(chash.NewHasher(pk).Hash(data, proof.Randomness))
+	if !merkle.VerifyProof(&root, &leaf, proof.Path) {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+func (file *File) buildTree() {
+	leaves := make([]*merkle.Hash, len(file.meta.Hashes))
+	for i := range leaves {
+		leaves[i] = (*merkle.Hash)(&file.meta.Hashes[i])
+	}
+	file.tree = merkle.NewTree(leaves)
+}
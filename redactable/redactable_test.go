@@ -0,0 +1,174 @@
+package redactable_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/clarenous/proxyot/chash"
+	"github.com/clarenous/proxyot/fileobj"
+	"github.com/clarenous/proxyot/redactable"
+)
+
+func TestUpdateBlock(t *testing.T) {
+	const blockSize, blockCount = 64, 7
+	td, err := chash.GenerateTrapdoor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := fileobj.NewMemFileObj(blockSize*blockCount, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := redactable.Build(obj, td, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := file.Root()
+
+	for i := int64(0); i < blockCount; i++ {
+		data, err := obj.GetBlock(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proof, err := file.Proof(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = file.VerifyBlock(i, data, proof); err != nil {
+			t.Fatalf("block %d: %v", i, err)
+		}
+	}
+
+	var target int64 = 4
+	newData := randomBlock(t, blockSize)
+	stale, err := file.Proof(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = file.UpdateBlock(target, newData); err != nil {
+		t.Fatal(err)
+	}
+	if file.Root() != root {
+		t.Fatal("root changed")
+	}
+	if got, _ := obj.GetBlock(target); string(got) != string(newData) {
+		t.Fatal("block not rewritten")
+	}
+	proof, err := file.Proof(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := file.Metadata().PublicKey
+	if err = redactable.VerifyBlock(pk, root, target, newData, proof); err != nil {
+		t.Fatal(err)
+	}
+	if err = redactable.VerifyBlock(pk, root, target, newData, stale); !errors.Is(err, redactable.ErrInvalidProof) {
+		t.Errorf("stale randomness: want ErrInvalidProof, got %v", err)
+	}
+	if err = redactable.VerifyBlock(pk, root, target-1, newData, proof); !errors.Is(err, redactable.ErrInvalidProof) {
+		t.Errorf("other index: want ErrInvalidProof, got %v", err)
+	}
+
+	// a verifier without trapdoor can not update
+	reader, err := redactable.Open(obj, file.Metadata().Public(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = reader.UpdateBlock(target, randomBlock(t, blockSize)); !errors.Is(err, chash.ErrNoTrapdoor) {
+		t.Errorf("want ErrNoTrapdoor, got %v", err)
+	}
+}
+
+func TestSidecar(t *testing.T) {
+	const blockSize, blockCount = 32, 5
+	dir, err := ioutil.TempDir("", "redactable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "file")
+	if err = ioutil.WriteFile(filename, randomBlock(t, blockSize*blockCount), 0600); err != nil {
+		t.Fatal(err)
+	}
+	td, err := chash.GenerateTrapdoor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj, err := fileobj.NewWritableFileObj(filename, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := redactable.Build(obj, td, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := file.Root()
+	if err = redactable.SaveMetadata(filename, file.Metadata()); err != nil {
+		t.Fatal(err)
+	}
+	obj.Close()
+
+	// reopen and update from the sidecar
+	if obj, err = fileobj.NewWritableFileObj(filename, blockSize); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := redactable.LoadMetadata(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file, err = redactable.Open(obj, meta, td); err != nil {
+		t.Fatal(err)
+	}
+	newData := randomBlock(t, blockSize)
+	if err = file.UpdateBlock(1, newData); err != nil {
+		t.Fatal(err)
+	}
+	if err = redactable.SaveMetadata(filename, file.Metadata()); err != nil {
+		t.Fatal(err)
+	}
+	obj.Close()
+
+	// a verifier reads the updated block
+	if obj, err = fileobj.NewFileObj(filename, blockSize); err != nil {
+		t.Fatal(err)
+	}
+	defer obj.Close()
+	if meta, err = redactable.LoadMetadata(filename); err != nil {
+		t.Fatal(err)
+	}
+	if file, err = redactable.Open(obj, meta.Public(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if file.Root() != root {
+		t.Fatal("root changed")
+	}
+	data, err := obj.GetBlock(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := file.Proof(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = file.VerifyBlock(1, data, proof); err != nil {
+		t.Fatal(err)
+	}
+
+	meta.Hashes[0][0] ^= 0xff
+	if _, err = redactable.Open(obj, meta, nil); !errors.Is(err, redactable.ErrInvalidMetadata) {
+		t.Errorf("tampered metadata: want ErrInvalidMetadata, got %v", err)
+	}
+}
+
+func randomBlock(t *testing.T, size int) []byte {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}